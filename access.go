@@ -0,0 +1,111 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package streamdeck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/matthewpi/streamdeck/internal/hid"
+)
+
+// AccessError is returned by AccessCheck when a Stream Deck was found but the
+// current user doesn't have permission to open it.
+type AccessError struct {
+	// Name of the DeviceType that was found.
+	Name string
+	// ProductID of the Device that was found.
+	ProductID uint16
+	// Path to the Device's node.
+	Path string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *AccessError) Error() string {
+	return fmt.Sprintf(
+		"streamdeck: permission denied opening %s at %s, add the following udev rule to /etc/udev/rules.d/70-streamdeck.rules and reconnect the device:\n%s",
+		e.Name, e.Path, UdevRule(e.ProductID),
+	)
+}
+
+// Unwrap returns the underlying error.
+func (e *AccessError) Unwrap() error {
+	return e.Err
+}
+
+// UdevRule returns a udev rule that allows the logged-in user to access the
+// Stream Deck with the given product ID.
+func UdevRule(productID uint16) string {
+	return fmt.Sprintf(
+		`SUBSYSTEM=="usb", ATTRS{idVendor}=="%04x", ATTRS{idProduct}=="%04x", MODE="0660", TAG+="uaccess"`,
+		elgatoVendorID, productID,
+	)
+}
+
+// AccessCheck checks that every Stream Deck that can be found is able to be
+// opened by the current user.
+//
+// If a Stream Deck can't be opened due to missing permissions, an AccessError
+// is returned containing the udev rule needed to grant access to the Device.
+// ErrDeviceNotFound is returned if no Stream Decks could be found.
+func AccessCheck(ctx context.Context, opts ...Option) error {
+	o := newOptions(opts)
+	devices, err := hid.Devices(o.path, o.logSkipped)
+	if err != nil {
+		return err
+	}
+
+	var found bool
+	knownDeviceTypes := DeviceTypes()
+	for _, d := range devices {
+		info := d.Info()
+		if info.VendorID != elgatoVendorID {
+			continue
+		}
+		for _, dt := range knownDeviceTypes {
+			if info.ProductID != dt.ProductID {
+				continue
+			}
+			found = true
+
+			if err := d.Open(ctx); err != nil {
+				if errors.Is(err, fs.ErrPermission) {
+					return &AccessError{Name: dt.Name, ProductID: dt.ProductID, Path: d.Path(), Err: err}
+				}
+				return err
+			}
+			if err := d.Close(ctx); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	if !found {
+		return ErrDeviceNotFound
+	}
+	return nil
+}
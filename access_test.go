@@ -0,0 +1,78 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package streamdeck_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matthewpi/streamdeck"
+)
+
+// TestAccessCheckSkipsInaccessibleNodes checks that USB device nodes and
+// buses that can't be accessed are skipped, and logged to the debug logger.
+func TestAccessCheckSkipsInaccessibleNodes(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions are not enforced for root")
+	}
+
+	dir := t.TempDir()
+	node := filepath.Join(dir, "001", "002")
+	bus := filepath.Join(dir, "002")
+	for _, d := range []string{filepath.Dir(node), bus} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(node, nil, 0o000); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(bus, 0o000); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chmod(bus, 0o755)
+	})
+
+	var buf bytes.Buffer
+	err := streamdeck.AccessCheck(context.Background(), streamdeck.WithPath(dir), streamdeck.WithDebugLogger(log.New(&buf, "", 0)))
+	if !errors.Is(err, streamdeck.ErrDeviceNotFound) {
+		t.Fatalf("expected the inaccessible nodes to be skipped, got %v", err)
+	}
+
+	logged := buf.String()
+	for _, path := range []string{node, bus} {
+		if !strings.Contains(logged, "skipped "+path+": ") {
+			t.Errorf("expected %s to be logged as skipped, got %q", path, logged)
+		}
+	}
+	if n := strings.Count(logged, "permission denied"); n != 2 {
+		t.Errorf("expected 2 permission errors to be logged, got %d: %q", n, logged)
+	}
+}
@@ -24,6 +24,10 @@ package button
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
 	"image/gif"
 	"time"
 
@@ -34,8 +38,8 @@ import (
 type Animated interface {
 	// Animate is called when the Button should start animating.
 	//
-	// It is expected that this function only exits if an error occurs or if the
-	// context is cancelled.
+	// It is expected that this function only exits if an error occurs, if the
+	// context is cancelled, or if the animation has finished.
 	//
 	// The closure passed should be called with an image processed by
 	// StreamDeck#ProcessImage which should be done ahead of time before Animate
@@ -43,70 +47,342 @@ type Animated interface {
 	Animate(context.Context, func(context.Context, []byte) error) error
 }
 
-// GIF represents an animated Button displaying a GIF
-type GIF struct {
-	gif    *gif.GIF
+// minFrameDelay is the minimum delay between frames of an animated image,
+// frames with a shorter delay will use defaultFrameDelay instead. This matches
+// the behaviour of most web browsers.
+const minFrameDelay = 20 * time.Millisecond
+
+// defaultFrameDelay is the delay used for frames of an animated image that
+// have a delay shorter than minFrameDelay.
+const defaultFrameDelay = 100 * time.Millisecond
+
+// Animation represents an animated Button displaying a sequence of frames.
+type Animation struct {
 	frames [][]byte
 	delay  []time.Duration
+
+	// maxFrameRate is the maximum number of frames displayed per second, zero
+	// means no limit.
+	maxFrameRate int
+	// loops is the number of times the animation is played, zero means the
+	// animation will loop forever.
+	loops int
 }
 
+// GIF represents an animated Button displaying a GIF.
+type GIF = Animation
+
 var (
-	_ Animated = (*GIF)(nil)
-	_ Button   = (*GIF)(nil)
+	_ Animated = (*Animation)(nil)
+	_ Button   = (*Animation)(nil)
 )
 
-// NewGIF returns a new animated Button that displays a GIF.
-func NewGIF(sd *streamdeck.StreamDeck, gif *gif.GIF) *GIF {
-	if len(gif.Image) != len(gif.Delay) {
-		panic("button: amount of frames does not match amount of delay")
-		return nil
+// NewAnimated returns a new animated Button that displays a sequence of
+// frames, each frame is displayed for its matching delay.
+//
+// This can be used to display animations from any format, as long as it can be
+// decoded into a sequence of images.
+func NewAnimated(sd *streamdeck.StreamDeck, frames []image.Image, delays []time.Duration) (*Animation, error) {
+	if len(frames) == 0 {
+		return nil, errors.New("button: animation has no frames")
+	}
+	if len(frames) != len(delays) {
+		return nil, fmt.Errorf("button: animation has %d frames but %d delays", len(frames), len(delays))
 	}
 
-	g := &GIF{
-		gif:    gif,
-		frames: make([][]byte, len(gif.Image)),
-		delay:  make([]time.Duration, len(gif.Delay)),
+	a := &Animation{
+		frames: make([][]byte, len(frames)),
+		delay:  make([]time.Duration, len(delays)),
 	}
-	for i, img := range gif.Image {
+	for i, img := range frames {
 		rawImage, err := sd.ProcessImage(img)
 		if err != nil {
-			return nil
+			return nil, fmt.Errorf("button: failed to process frame %d: %w", i, err)
+		}
+		a.frames[i] = rawImage
+	}
+	for i, v := range delays {
+		if v <= 0 {
+			return nil, fmt.Errorf("button: frame %d has a non-positive delay", i)
 		}
-		g.frames[i] = rawImage
+		a.delay[i] = v
 	}
+	return a, nil
+}
+
+// NewGIF returns a new animated Button that displays a GIF.
+func NewGIF(sd *streamdeck.StreamDeck, gif *gif.GIF) (*GIF, error) {
+	if gif == nil || len(gif.Image) == 0 {
+		return nil, errors.New("button: gif has no frames")
+	}
+	if len(gif.Image) != len(gif.Delay) {
+		return nil, fmt.Errorf("button: gif has %d frames but %d delays", len(gif.Image), len(gif.Delay))
+	}
+
+	frames, err := gifFrames(gif, len(gif.Image))
+	if err != nil {
+		return nil, fmt.Errorf("button: invalid gif: %w", err)
+	}
+	delays := make([]time.Duration, len(gif.Delay))
 	for i, v := range gif.Delay {
 		// Convert the GIF duration (from 100ths of a second) to a proper
 		// time.Duration
-		g.delay[i] = time.Duration(v) * 10 * time.Millisecond
+		delays[i] = frameDelay(time.Duration(v) * 10 * time.Millisecond)
+	}
+	return NewAnimated(sd, frames, delays)
+}
+
+// NewImageFromGIF returns a new static Button displaying only the first frame
+// of a GIF, this avoids the cost of animating a GIF when motion isn't needed.
+//
+// An error will be returned if the GIF has no frames.
+func NewImageFromGIF(sd *streamdeck.StreamDeck, g *gif.GIF) (*Image, error) {
+	if g == nil || len(g.Image) == 0 {
+		return nil, errors.New("button: gif has no frames")
+	}
+
+	// The first frame may only cover part of the GIF, composite it the same
+	// way as the animated GIF so it is scaled the same.
+	frames, err := gifFrames(g, 1)
+	if err != nil {
+		return nil, fmt.Errorf("button: invalid gif: %w", err)
+	}
+	rawImage, err := sd.ProcessImage(frames[0])
+	if err != nil {
+		return nil, err
+	}
+	return NewImage(rawImage), nil
+}
+
+// gifFrames returns the first n frames of a GIF composited onto a canvas the
+// size of the GIF, applying the disposal method of each frame in turn.
+//
+// Frames of a GIF may only cover part of the canvas and rely on the previous
+// frames for the rest of the image, so they can't be displayed on their own.
+func gifFrames(g *gif.GIF, n int) ([]image.Image, error) {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		// Fall back to the area covered by the frames if the GIF doesn't
+		// specify its size.
+		bounds = image.Rectangle{}
+		for _, img := range g.Image {
+			bounds = bounds.Union(img.Bounds())
+		}
+	}
+
+	if err := checkCanvas(bounds, n); err != nil {
+		return nil, err
 	}
 
-	return g
+	c := newCompositor(bounds)
+	frames := make([]image.Image, n)
+	for i, img := range g.Image[:n] {
+		disposal := disposeNone
+		if i < len(g.Disposal) {
+			switch g.Disposal[i] {
+			case gif.DisposalBackground:
+				disposal = disposeBackground
+			case gif.DisposalPrevious:
+				disposal = disposePrevious
+			}
+		}
+		frames[i] = c.draw(img.Bounds(), img, draw.Over, disposal)
+	}
+	return frames, nil
+}
+
+// frameDelay returns the delay used for a frame of an animation, delays that
+// are too short to be displayed use defaultFrameDelay instead.
+func frameDelay(d time.Duration) time.Duration {
+	if d < minFrameDelay {
+		return defaultFrameDelay
+	}
+	return d
+}
+
+// frameDisposal is how the area covered by a frame of an animation is
+// disposed of before the next frame is drawn.
+type frameDisposal uint8
+
+const (
+	// disposeNone leaves the frame on the canvas.
+	disposeNone frameDisposal = iota
+	// disposeBackground clears the area covered by the frame.
+	disposeBackground
+	// disposePrevious restores the canvas to how it was before the frame was
+	// drawn.
+	disposePrevious
+)
+
+// maxCanvasPixels is the largest canvas, in pixels, an animation may be
+// composited onto. Buttons are at most a few hundred pixels wide, anything
+// larger than this is almost certainly a malformed or malicious file.
+const maxCanvasPixels = 4096 * 4096
+
+// maxAnimationPixels is the largest number of pixels, across every frame, an
+// animation may be composited into. Every frame is a copy of the canvas, so
+// this limits the memory used by a long animation with a large canvas.
+const maxAnimationPixels = 64 << 20
+
+// checkCanvas checks that n frames may be composited onto a canvas with the
+// given bounds without using an unreasonable amount of memory, it must be
+// called before the canvas is allocated.
+func checkCanvas(bounds image.Rectangle, n int) error {
+	if bounds.Empty() {
+		return errors.New("canvas is empty")
+	}
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxCanvasPixels/height {
+		return fmt.Errorf("canvas of %dx%d is too large", width, height)
+	}
+	if n > maxAnimationPixels/(width*height) {
+		return fmt.Errorf("%d frames of %dx%d are too large", n, width, height)
+	}
+	return nil
+}
+
+// compositor draws the frames of an animation onto a canvas, frames of most
+// animated formats may only cover part of the canvas and rely on the previous
+// frames for the rest of the image.
+type compositor struct {
+	canvas *image.RGBA
+}
+
+// newCompositor returns a compositor with a transparent canvas, the bounds
+// must be checked using checkCanvas first.
+func newCompositor(bounds image.Rectangle) *compositor {
+	return &compositor{canvas: image.NewRGBA(bounds)}
+}
+
+// draw draws a frame onto the area r of the canvas using op and returns a copy
+// of the canvas, the frame is then disposed of using disposal.
+func (c *compositor) draw(r image.Rectangle, img image.Image, op draw.Op, disposal frameDisposal) image.Image {
+	var previous *image.RGBA
+	if disposal == disposePrevious {
+		previous = cloneRGBA(c.canvas)
+	}
+	draw.Draw(c.canvas, r, img, img.Bounds().Min, op)
+	frame := cloneRGBA(c.canvas)
+
+	switch disposal {
+	case disposeBackground:
+		draw.Draw(c.canvas, r, image.Transparent, image.Point{}, draw.Src)
+	case disposePrevious:
+		c.canvas = previous
+	}
+	return frame
+}
+
+// cloneRGBA returns a copy of an image.
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	res := *img
+	res.Pix = append([]uint8(nil), img.Pix...)
+	return &res
+}
+
+// NewGIFWithLoops returns a new animated Button that displays a GIF a fixed
+// number of times, leaving the last frame displayed once it has finished. A
+// loops value of zero will loop forever.
+func NewGIFWithLoops(sd *streamdeck.StreamDeck, gif *gif.GIF, loops int) (*GIF, error) {
+	g, err := NewGIF(sd, gif)
+	if err != nil {
+		return nil, err
+	}
+	return g.SetLoops(loops), nil
+}
+
+// SetLoops sets the number of times the animation is played, leaving the last
+// frame displayed once it has finished. A value of zero will loop forever.
+func (a *Animation) SetLoops(loops int) *Animation {
+	a.loops = loops
+	return a
+}
+
+// SetMaxFrameRate sets the maximum number of frames displayed per second, a
+// value of zero removes the limit. Frames will be skipped in order to stay
+// under the limit.
+func (a *Animation) SetMaxFrameRate(fps int) *Animation {
+	a.maxFrameRate = fps
+	return a
 }
 
 // Animate satisfies the Animated interface.
-func (g *GIF) Animate(ctx context.Context, fn func(context.Context, []byte) error) error {
+//
+// Frames are scheduled relative to when the animation started rather than when
+// the previous frame was displayed, if displaying a frame takes longer than its
+// delay, any frames that should have already been displayed are skipped so the
+// animation doesn't fall behind.
+func (a *Animation) Animate(ctx context.Context, fn func(context.Context, []byte) error) error {
+	var minInterval time.Duration
+	if a.maxFrameRate > 0 {
+		minInterval = time.Second / time.Duration(a.maxFrameRate)
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	// A single frame animation is displayed as a static image.
+	if len(a.frames) == 1 {
+		return fn(ctx, a.frames[0])
+	}
+
+	start := time.Now()
+	var (
+		// i is the index of the frame to display.
+		i int
+		// at is when the frame should be displayed, relative to start.
+		at time.Duration
+		// loop is the number of times the animation has been played.
+		loop int
+	)
+
+	// advance moves to the next frame, returning false if the animation has
+	// finished.
+	advance := func() bool {
+		at += a.delay[i]
+		i++
+		if i < len(a.frames) {
+			return true
+		}
+		i = 0
+		loop++
+		return a.loops == 0 || loop < a.loops
+	}
+
 	for {
+		if err := fn(ctx, a.frames[i]); err != nil {
+			return err
+		}
+		last := at
+		if !advance() {
+			return nil
+		}
+
+		// Find the next frame to display, skipping any frames that should have
+		// already finished displaying or that would exceed the frame rate.
+		now := time.Since(start)
+		for at+a.delay[i] <= now || at-last < minInterval {
+			if !advance() {
+				// Always finish the animation on the last frame.
+				return fn(ctx, a.frames[len(a.frames)-1])
+			}
+		}
+
+		timer.Reset(at - now)
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		default:
-			for i, f := range g.frames {
-				if err := fn(ctx, f); err != nil {
-					return err
-				}
-
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				// TODO: https://tylerstiene.ca/blog/careful-gos-standard-ticker-is-not-realtime/
-				case <-time.NewTimer(g.delay[i]).C:
-				}
-			}
+		case <-timer.C:
 		}
 	}
 }
 
 // Image satisfies the Button interface.
-func (*GIF) Image() []byte {
+func (a *Animation) Image() []byte {
+	// A single frame animation is displayed as a static image.
+	if len(a.frames) == 1 {
+		return a.frames[0]
+	}
 	return nil
 }
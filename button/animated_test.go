@@ -0,0 +1,160 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package button
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"strings"
+	"testing"
+)
+
+var (
+	red         = color.RGBA{R: 0xff, A: 0xff}
+	blue        = color.RGBA{B: 0xff, A: 0xff}
+	transparent = color.RGBA{}
+)
+
+// paletted returns a frame of a GIF covering r, filled with c.
+func paletted(r image.Rectangle, c color.Color) *image.Paletted {
+	img := image.NewPaletted(r, color.Palette{color.Transparent, c})
+	for i := range img.Pix {
+		img.Pix[i] = 1
+	}
+	return img
+}
+
+// checkPixels checks the colour of pixels in a composited frame.
+func checkPixels(t *testing.T, frame int, img image.Image, want map[image.Point]color.RGBA) {
+	t.Helper()
+
+	if b := img.Bounds(); b != image.Rect(0, 0, 4, 4) {
+		t.Errorf("frame %d: expected the canvas bounds, got %v", frame, b)
+	}
+	for p, c := range want {
+		if got := color.RGBAModel.Convert(img.At(p.X, p.Y)); got != c {
+			t.Errorf("frame %d: pixel %v: expected %v, got %v", frame, p, c, got)
+		}
+	}
+}
+
+func TestGIFFrames(t *testing.T) {
+	// The second frame only covers the bottom right quarter of the canvas.
+	full := image.Rect(0, 0, 4, 4)
+	quarter := image.Rect(2, 2, 4, 4)
+	for _, tc := range []struct {
+		name     string
+		disposal byte
+		// corner is the colour of the top left corner in the third frame.
+		corner color.RGBA
+	}{
+		{name: "none", disposal: gif.DisposalNone, corner: red},
+		{name: "background", disposal: gif.DisposalBackground, corner: transparent},
+		{name: "previous", disposal: gif.DisposalPrevious, corner: transparent},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := &gif.GIF{
+				Image: []*image.Paletted{
+					paletted(full, red),
+					paletted(quarter, blue),
+					paletted(image.Rect(0, 0, 1, 1), blue),
+				},
+				Delay:    []int{0, 0, 0},
+				Disposal: []byte{tc.disposal, gif.DisposalNone, gif.DisposalNone},
+				Config:   image.Config{Width: 4, Height: 4},
+			}
+			frames, err := gifFrames(g, len(g.Image))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkPixels(t, 0, frames[0], map[image.Point]color.RGBA{{0, 0}: red, {3, 3}: red})
+			// The first frame is only disposed of after it has been displayed.
+			second := map[image.Point]color.RGBA{{0, 0}: tc.corner, {3, 3}: blue}
+			checkPixels(t, 1, frames[1], second)
+			checkPixels(t, 2, frames[2], map[image.Point]color.RGBA{{0, 0}: blue, {1, 1}: tc.corner, {3, 3}: blue})
+		})
+	}
+}
+
+func TestGIFFramesPrevious(t *testing.T) {
+	// A frame disposed of by restoring the previous canvas leaves the canvas
+	// as it was before the frame was drawn.
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			paletted(image.Rect(0, 0, 4, 4), red),
+			paletted(image.Rect(2, 2, 4, 4), blue),
+			paletted(image.Rect(0, 0, 1, 1), blue),
+		},
+		Delay:    []int{0, 0, 0},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalPrevious, gif.DisposalNone},
+		Config:   image.Config{Width: 4, Height: 4},
+	}
+	frames, err := gifFrames(g, len(g.Image))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkPixels(t, 1, frames[1], map[image.Point]color.RGBA{{0, 0}: red, {3, 3}: blue})
+	checkPixels(t, 2, frames[2], map[image.Point]color.RGBA{{0, 0}: blue, {3, 3}: red})
+}
+
+func TestGIFFramesFirst(t *testing.T) {
+	// A first frame covering only part of the canvas is placed on the canvas.
+	g := &gif.GIF{
+		Image:  []*image.Paletted{paletted(image.Rect(2, 2, 4, 4), blue)},
+		Delay:  []int{0},
+		Config: image.Config{Width: 4, Height: 4},
+	}
+	frames, err := gifFrames(g, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPixels(t, 0, frames[0], map[image.Point]color.RGBA{{0, 0}: transparent, {3, 3}: blue})
+}
+
+func TestGIFFramesTooLarge(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config image.Config
+		n      int
+	}{
+		{name: "huge canvas", config: image.Config{Width: 65535, Height: 65535}, n: 1},
+		{name: "too many frames", config: image.Config{Width: 4096, Height: 4096}, n: 5},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := &gif.GIF{Config: tc.config}
+			for i := 0; i < tc.n; i++ {
+				g.Image = append(g.Image, paletted(image.Rect(0, 0, 1, 1), red))
+				g.Delay = append(g.Delay, 0)
+			}
+			_, err := gifFrames(g, tc.n)
+			if err == nil || !strings.Contains(err.Error(), "too large") {
+				t.Errorf("expected the canvas to be too large, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package button
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"time"
+
+	"github.com/matthewpi/streamdeck"
+)
+
+// pngSignature is the signature at the start of every PNG file.
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// APNG frame disposal and blend operations.
+const (
+	apngDisposeNone       = 0
+	apngDisposeBackground = 1
+	apngDisposePrevious   = 2
+
+	apngBlendSource = 0
+	apngBlendOver   = 1
+)
+
+// NewAPNG returns a new animated Button that displays an animated PNG decoded
+// from r.
+//
+// A PNG without any animation is displayed as a static image.
+func NewAPNG(sd *streamdeck.StreamDeck, r io.Reader) (*Animation, error) {
+	frames, delays, err := decodeAPNG(r)
+	if err != nil {
+		return nil, fmt.Errorf("button: failed to decode apng: %w", err)
+	}
+	return NewAnimated(sd, frames, delays)
+}
+
+// pngChunk is a chunk of a PNG file.
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// apngFrame is a frame of an animated PNG, as described by an fcTL chunk.
+type apngFrame struct {
+	bounds   image.Rectangle
+	delay    time.Duration
+	dispose  byte
+	blend    byte
+	data     []byte
+	hasImage bool
+}
+
+// decodeAPNG decodes the frames of an animated PNG, compositing them onto the
+// canvas so every frame is a complete image.
+//
+// The standard library can only decode the default image of a PNG, so every
+// frame is decoded by rewriting it as a standalone PNG.
+func decodeAPNG(r io.Reader) ([]image.Image, []time.Duration, error) {
+	chunks, err := readPNGChunks(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(chunks) == 0 || chunks[0].typ != "IHDR" || len(chunks[0].data) != 13 {
+		return nil, nil, errors.New("missing IHDR chunk")
+	}
+	ihdr := chunks[0].data
+	canvas := image.Rect(0, 0, int(binary.BigEndian.Uint32(ihdr[0:4])), int(binary.BigEndian.Uint32(ihdr[4:8])))
+	if err := checkCanvas(canvas, 1); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		// shared are the chunks before the image data that apply to every
+		// frame, like the palette.
+		shared   []pngChunk
+		frames   []*apngFrame
+		animated bool
+		seenData bool
+	)
+	for _, c := range chunks[1:] {
+		switch c.typ {
+		case "acTL":
+			animated = true
+		case "fcTL":
+			f, err := parseFCTL(c.data)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !f.bounds.In(canvas) {
+				return nil, nil, fmt.Errorf("frame %d is outside of the canvas", len(frames))
+			}
+			frames = append(frames, f)
+		case "IDAT":
+			seenData = true
+			// The default image is only part of the animation if a frame
+			// control chunk comes before it.
+			if len(frames) == 1 {
+				frames[0].data = append(frames[0].data, c.data...)
+				frames[0].hasImage = true
+			}
+		case "fdAT":
+			if len(frames) == 0 || len(c.data) < 4 {
+				return nil, nil, errors.New("unexpected fdAT chunk")
+			}
+			f := frames[len(frames)-1]
+			f.data = append(f.data, c.data[4:]...)
+			f.hasImage = true
+		case "IEND":
+		default:
+			if !seenData {
+				shared = append(shared, c)
+			}
+		}
+	}
+
+	// A PNG without an animation control chunk is a static image.
+	if !animated {
+		img, err := png.Decode(bytes.NewReader(encodePNG(ihdr, shared, chunksData(chunks, "IDAT"))))
+		if err != nil {
+			return nil, nil, err
+		}
+		return []image.Image{img}, []time.Duration{defaultFrameDelay}, nil
+	}
+	if len(frames) == 0 {
+		return nil, nil, errors.New("apng has no frames")
+	}
+	if err := checkCanvas(canvas, len(frames)); err != nil {
+		return nil, nil, err
+	}
+
+	c := newCompositor(canvas)
+	images := make([]image.Image, len(frames))
+	delays := make([]time.Duration, len(frames))
+	for i, f := range frames {
+		if !f.hasImage {
+			return nil, nil, fmt.Errorf("frame %d has no image data", i)
+		}
+
+		// Rewrite the frame as a PNG the size of the frame.
+		frameIHDR := append([]byte(nil), ihdr...)
+		binary.BigEndian.PutUint32(frameIHDR[0:4], uint32(f.bounds.Dx()))
+		binary.BigEndian.PutUint32(frameIHDR[4:8], uint32(f.bounds.Dy()))
+		img, err := png.Decode(bytes.NewReader(encodePNG(frameIHDR, shared, f.data)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode frame %d: %w", i, err)
+		}
+
+		op := draw.Over
+		if f.blend == apngBlendSource {
+			op = draw.Src
+		}
+		disposal := disposeNone
+		switch f.dispose {
+		case apngDisposeBackground:
+			disposal = disposeBackground
+		case apngDisposePrevious:
+			// There is nothing to restore for the first frame, so it is
+			// cleared instead.
+			disposal = disposePrevious
+			if i == 0 {
+				disposal = disposeBackground
+			}
+		}
+		images[i] = c.draw(f.bounds, img, op, disposal)
+		delays[i] = frameDelay(f.delay)
+	}
+	return images, delays, nil
+}
+
+// parseFCTL parses a frame control chunk.
+func parseFCTL(b []byte) (*apngFrame, error) {
+	if len(b) != 26 {
+		return nil, errors.New("malformed fcTL chunk")
+	}
+	var (
+		width  = int(binary.BigEndian.Uint32(b[4:8]))
+		height = int(binary.BigEndian.Uint32(b[8:12]))
+		x      = int(binary.BigEndian.Uint32(b[12:16]))
+		y      = int(binary.BigEndian.Uint32(b[16:20]))
+		num    = binary.BigEndian.Uint16(b[20:22])
+		den    = binary.BigEndian.Uint16(b[22:24])
+	)
+	if width <= 0 || height <= 0 || b[24] > apngDisposePrevious || b[25] > apngBlendOver {
+		return nil, errors.New("malformed fcTL chunk")
+	}
+	// A denominator of zero means the delay is in 100ths of a second.
+	if den == 0 {
+		den = 100
+	}
+	return &apngFrame{
+		bounds:  image.Rect(x, y, x+width, y+height),
+		delay:   time.Duration(num) * time.Second / time.Duration(den),
+		dispose: b[24],
+		blend:   b[25],
+	}, nil
+}
+
+// readPNGChunks reads all chunks of a PNG file, checking their CRCs.
+func readPNGChunks(r io.Reader) ([]pngChunk, error) {
+	var sig [len(pngSignature)]byte
+	if _, err := io.ReadFull(r, sig[:]); err != nil {
+		return nil, err
+	}
+	if string(sig[:]) != pngSignature {
+		return nil, errors.New("not a png file")
+	}
+
+	var chunks []pngChunk
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil, err
+		}
+		length := binary.BigEndian.Uint32(hdr[:4])
+		if length > 1<<31-1 {
+			return nil, errors.New("chunk too large")
+		}
+		// Read the chunk without trusting its length, so a malformed length
+		// can't cause a large allocation for data that doesn't exist.
+		data, err := io.ReadAll(io.LimitReader(r, int64(length)+4))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) != int(length)+4 {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		crc := crc32.NewIEEE()
+		_, _ = crc.Write(hdr[4:])
+		_, _ = crc.Write(data[:length])
+		if crc.Sum32() != binary.BigEndian.Uint32(data[length:]) {
+			return nil, errors.New("invalid chunk checksum")
+		}
+
+		c := pngChunk{typ: string(hdr[4:]), data: data[:length]}
+		chunks = append(chunks, c)
+		if c.typ == "IEND" {
+			return chunks, nil
+		}
+	}
+}
+
+// chunksData returns the data of all chunks of a type, concatenated.
+func chunksData(chunks []pngChunk, typ string) []byte {
+	var res []byte
+	for _, c := range chunks {
+		if c.typ == typ {
+			res = append(res, c.data...)
+		}
+	}
+	return res
+}
+
+// encodePNG returns a PNG file made up of the given IHDR chunk, the shared
+// chunks, and the image data.
+func encodePNG(ihdr []byte, shared []pngChunk, data []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(pngSignature)
+	writePNGChunk(&b, "IHDR", ihdr)
+	for _, c := range shared {
+		writePNGChunk(&b, c.typ, c.data)
+	}
+	writePNGChunk(&b, "IDAT", data)
+	writePNGChunk(&b, "IEND", nil)
+	return b.Bytes()
+}
+
+// writePNGChunk writes a chunk of a PNG file.
+func writePNGChunk(b *bytes.Buffer, typ string, data []byte) {
+	var v [4]byte
+	binary.BigEndian.PutUint32(v[:], uint32(len(data)))
+	b.Write(v[:])
+	b.WriteString(typ)
+	b.Write(data)
+
+	crc := crc32.NewIEEE()
+	_, _ = crc.Write([]byte(typ))
+	_, _ = crc.Write(data)
+	binary.BigEndian.PutUint32(v[:], crc.Sum32())
+	b.Write(v[:])
+}
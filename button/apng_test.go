@@ -0,0 +1,272 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package button
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testAPNGFrame is a frame of an animated PNG built by encodeTestAPNG.
+type testAPNGFrame struct {
+	bounds  image.Rectangle
+	c       color.Color
+	delay   [2]uint16
+	dispose byte
+	blend   byte
+}
+
+// half is a half transparent colour.
+var half = color.NRGBA{G: 0xff, A: 0x80}
+
+// testAPNGPalette is the palette used by every frame built by encodeTestAPNG.
+var testAPNGPalette = color.Palette{transparent, red, blue, half}
+
+// encodeTestAPNG returns an animated PNG with a 4x4 canvas made up of frames
+// filled with a single colour.
+func encodeTestAPNG(t *testing.T, frames []testAPNGFrame) []byte {
+	t.Helper()
+
+	var (
+		b   bytes.Buffer
+		seq uint32
+	)
+	b.WriteString(pngSignature)
+	for i, f := range frames {
+		// Every frame of an animated PNG uses the same colour type, so use
+		// the same palette for every frame.
+		img := image.NewPaletted(image.Rect(0, 0, f.bounds.Dx(), f.bounds.Dy()), testAPNGPalette)
+		draw.Draw(img, img.Bounds(), image.NewUniform(f.c), image.Point{}, draw.Src)
+		var enc bytes.Buffer
+		if err := png.Encode(&enc, img); err != nil {
+			t.Fatal(err)
+		}
+		chunks, err := readPNGChunks(&enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if i == 0 {
+			ihdr := append([]byte(nil), chunks[0].data...)
+			binary.BigEndian.PutUint32(ihdr[0:4], 4)
+			binary.BigEndian.PutUint32(ihdr[4:8], 4)
+			writePNGChunk(&b, "IHDR", ihdr)
+			actl := make([]byte, 8)
+			binary.BigEndian.PutUint32(actl[0:4], uint32(len(frames)))
+			writePNGChunk(&b, "acTL", actl)
+			for _, c := range chunks[1:] {
+				if c.typ == "PLTE" || c.typ == "tRNS" {
+					writePNGChunk(&b, c.typ, c.data)
+				}
+			}
+		}
+
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:4], seq)
+		binary.BigEndian.PutUint32(fctl[4:8], uint32(f.bounds.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:12], uint32(f.bounds.Dy()))
+		binary.BigEndian.PutUint32(fctl[12:16], uint32(f.bounds.Min.X))
+		binary.BigEndian.PutUint32(fctl[16:20], uint32(f.bounds.Min.Y))
+		binary.BigEndian.PutUint16(fctl[20:22], f.delay[0])
+		binary.BigEndian.PutUint16(fctl[22:24], f.delay[1])
+		fctl[24] = f.dispose
+		fctl[25] = f.blend
+		writePNGChunk(&b, "fcTL", fctl)
+		seq++
+
+		data := chunksData(chunks, "IDAT")
+		if i == 0 {
+			writePNGChunk(&b, "IDAT", data)
+			continue
+		}
+		fdat := make([]byte, 4, 4+len(data))
+		binary.BigEndian.PutUint32(fdat, seq)
+		writePNGChunk(&b, "fdAT", append(fdat, data...))
+		seq++
+	}
+	writePNGChunk(&b, "IEND", nil)
+	return b.Bytes()
+}
+
+func TestDecodeAPNG(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		dispose byte
+		blend   byte
+		// centre is the colour of the bottom right quarter in the third frame.
+		centre color.RGBA
+	}{
+		{name: "none", dispose: apngDisposeNone, blend: apngBlendOver, centre: over(half, blue)},
+		{name: "background", dispose: apngDisposeBackground, blend: apngBlendOver, centre: over(half, transparent)},
+		{name: "previous", dispose: apngDisposePrevious, blend: apngBlendOver, centre: over(half, red)},
+		{name: "source", dispose: apngDisposeNone, blend: apngBlendSource, centre: over(half, transparent)},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			b := encodeTestAPNG(t, []testAPNGFrame{
+				{bounds: image.Rect(0, 0, 4, 4), c: red, delay: [2]uint16{1, 10}},
+				{bounds: image.Rect(2, 2, 4, 4), c: blue, delay: [2]uint16{50, 0}, dispose: tc.dispose},
+				{bounds: image.Rect(2, 2, 4, 4), c: half, delay: [2]uint16{0, 0}, blend: tc.blend},
+			})
+			frames, delays, err := decodeAPNG(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(frames) != 3 {
+				t.Fatalf("expected 3 frames, got %d", len(frames))
+			}
+
+			wantDelays := []time.Duration{100 * time.Millisecond, 500 * time.Millisecond, defaultFrameDelay}
+			for i, d := range delays {
+				if d != wantDelays[i] {
+					t.Errorf("frame %d: expected a delay of %v, got %v", i, wantDelays[i], d)
+				}
+			}
+
+			checkPixels(t, 0, frames[0], map[image.Point]color.RGBA{{0, 0}: red, {3, 3}: red})
+			checkPixels(t, 1, frames[1], map[image.Point]color.RGBA{{0, 0}: red, {3, 3}: blue})
+			checkPixels(t, 2, frames[2], map[image.Point]color.RGBA{{0, 0}: red, {3, 3}: tc.centre})
+		})
+	}
+}
+
+// over returns the colour of src drawn over dst.
+func over(src, dst color.Color) color.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.Set(0, 0, dst)
+	draw.Draw(img, img.Bounds(), image.NewUniform(src), image.Point{}, draw.Over)
+	return img.RGBAAt(0, 0)
+}
+
+func TestDecodeAPNGStatic(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	draw.Draw(img, img.Bounds(), image.NewUniform(blue), image.Point{}, draw.Src)
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		t.Fatal(err)
+	}
+
+	frames, delays, err := decodeAPNG(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 1 || len(delays) != 1 {
+		t.Fatalf("expected a single frame, got %d", len(frames))
+	}
+	if got := color.RGBAModel.Convert(frames[0].At(1, 1)); got != blue {
+		t.Errorf("expected %v, got %v", blue, got)
+	}
+}
+
+func TestDecodeAPNGMalformed(t *testing.T) {
+	b := encodeTestAPNG(t, []testAPNGFrame{
+		{bounds: image.Rect(0, 0, 4, 4), c: red},
+		{bounds: image.Rect(2, 2, 6, 6), c: blue},
+	})
+	if _, _, err := decodeAPNG(bytes.NewReader(b)); err == nil {
+		t.Error("expected a frame outside of the canvas to be rejected")
+	}
+
+	b = encodeTestAPNG(t, []testAPNGFrame{{bounds: image.Rect(0, 0, 4, 4), c: red}})
+	b[len(b)-5] ^= 0xff
+	if _, _, err := decodeAPNG(bytes.NewReader(b)); err == nil {
+		t.Error("expected a corrupted checksum to be rejected")
+	}
+}
+
+// craftedAPNG returns an animated PNG with the given canvas size and n 1x1
+// frames, without any valid image data.
+func craftedAPNG(width, height uint32, n int) []byte {
+	var b bytes.Buffer
+	b.WriteString(pngSignature)
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:4], width)
+	binary.BigEndian.PutUint32(ihdr[4:8], height)
+	ihdr[8] = 8 // Bit depth
+	ihdr[9] = 6 // RGBA
+	writePNGChunk(&b, "IHDR", ihdr)
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:4], uint32(n))
+	writePNGChunk(&b, "acTL", actl)
+	for i := 0; i < n; i++ {
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:4], uint32(2*i))
+		binary.BigEndian.PutUint32(fctl[4:8], 1)
+		binary.BigEndian.PutUint32(fctl[8:12], 1)
+		writePNGChunk(&b, "fcTL", fctl)
+		fdat := make([]byte, 5)
+		binary.BigEndian.PutUint32(fdat[0:4], uint32(2*i+1))
+		writePNGChunk(&b, "fdAT", fdat)
+	}
+	writePNGChunk(&b, "IEND", nil)
+	return b.Bytes()
+}
+
+func TestDecodeAPNGTooLarge(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		b    []byte
+		want string
+	}{
+		{name: "empty canvas", b: craftedAPNG(0, 0, 1), want: "canvas is empty"},
+		{name: "huge canvas", b: craftedAPNG(0x7fffffff, 0x7fffffff, 1), want: "too large"},
+		{name: "too many frames", b: craftedAPNG(4096, 4096, 5), want: "too large"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := decodeAPNG(bytes.NewReader(tc.b))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected an error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestReadPNGChunksTruncated(t *testing.T) {
+	// A chunk claiming to be almost 2 GiB must be rejected once the data runs
+	// out, rather than allocating the whole chunk up front.
+	var b bytes.Buffer
+	b.WriteString(pngSignature)
+	_ = binary.Write(&b, binary.BigEndian, uint32(1<<31-1))
+	b.WriteString("IDAT")
+	b.Write(make([]byte, 16))
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := readPNGChunks(&b); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Errorf("expected less than 1 MiB to be allocated, got %d bytes", n)
+	}
+}
@@ -22,11 +22,31 @@
 
 package button
 
+import "context"
+
 // Button represents a button that can be displayed on a StreamDeck using a View.
 type Button interface {
 	Image() []byte
 }
 
+// Pressable represents a Button that handles its own press events.
+//
+// When used with a Buttons View, OnPress will be called whenever the Button is
+// pressed and the Button's image will be updated afterwards.
+type Pressable interface {
+	// OnPress is called when the Button is pressed.
+	OnPress(context.Context) error
+}
+
+// Releasable represents a Button that handles its own release events.
+//
+// When used with a Buttons View, OnRelease will be called whenever the Button
+// is released and the Button's image will be updated afterwards.
+type Releasable interface {
+	// OnRelease is called when the Button is released.
+	OnRelease(context.Context) error
+}
+
 // Image represents a static Button displaying an image.
 type Image struct {
 	img []byte
@@ -35,6 +55,9 @@ type Image struct {
 var _ Button = (*Image)(nil)
 
 // NewImage returns a new static Button displaying an image.
+//
+// The same Image may be set on multiple buttons, the image only needs to be
+// processed once.
 func NewImage(v []byte) *Image {
 	return &Image{img: v}
 }
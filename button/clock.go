@@ -0,0 +1,152 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package button
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/matthewpi/streamdeck"
+)
+
+// Clock represents an animated Button displaying the current time.
+type Clock struct {
+	sd *streamdeck.StreamDeck
+
+	clockMx  sync.Mutex
+	layout   string
+	location *time.Location
+	opts     IconTextOptions
+}
+
+var (
+	_ Animated = (*Clock)(nil)
+	_ Button   = (*Clock)(nil)
+)
+
+// NewClock returns a new animated Button displaying the current time formatted
+// using layout, see time.Time#Format. The time is displayed in the local time
+// zone unless a different location is set using Clock#SetLocation.
+//
+// The Button is only updated when the formatted time changes, so a layout
+// without seconds will only update once per minute.
+func NewClock(sd *streamdeck.StreamDeck, layout string) *Clock {
+	return &Clock{
+		sd:       sd,
+		layout:   layout,
+		location: time.Local,
+	}
+}
+
+// SetLayout sets the layout used to format the time.
+func (c *Clock) SetLayout(layout string) *Clock {
+	c.clockMx.Lock()
+	c.layout = layout
+	c.clockMx.Unlock()
+	return c
+}
+
+// SetLocation sets the time zone the time is displayed in.
+func (c *Clock) SetLocation(loc *time.Location) *Clock {
+	c.clockMx.Lock()
+	c.location = loc
+	c.clockMx.Unlock()
+	return c
+}
+
+// SetTextOptions sets the options used to render the time, see NewText.
+func (c *Clock) SetTextOptions(opts IconTextOptions) *Clock {
+	c.clockMx.Lock()
+	c.opts = opts
+	c.clockMx.Unlock()
+	return c
+}
+
+// Image satisfies the Button interface, it returns an image of the current
+// time or nil if the image could not be rendered.
+func (c *Clock) Image() []byte {
+	v, err := c.render(c.format(time.Now()))
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// Animate satisfies the Animated interface.
+//
+// The time is checked at the start of every second, an image is only rendered
+// and displayed when the formatted time has changed.
+func (c *Clock) Animate(ctx context.Context, fn func(context.Context, []byte) error) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	var last string
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		if text := c.format(now); text != last {
+			v, err := c.render(text)
+			if err != nil {
+				return err
+			}
+			if err := fn(ctx, v); err != nil {
+				return err
+			}
+			last = text
+		}
+
+		// Wait until the start of the next second so the displayed time
+		// changes at the same time as the clock it is based on.
+		timer.Reset(now.Truncate(time.Second).Add(time.Second).Sub(time.Now()))
+	}
+}
+
+// format formats t using the Clock's layout and location.
+func (c *Clock) format(t time.Time) string {
+	c.clockMx.Lock()
+	defer c.clockMx.Unlock()
+
+	if c.location != nil {
+		t = t.In(c.location)
+	}
+	return t.Format(c.layout)
+}
+
+// render renders text to an image processed for the Stream Deck.
+func (c *Clock) render(text string) ([]byte, error) {
+	c.clockMx.Lock()
+	opts := c.opts
+	c.clockMx.Unlock()
+
+	img, err := RenderText(c.sd.Device().ImageDimensions(), text, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.sd.ProcessImage(img)
+}
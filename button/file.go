@@ -0,0 +1,67 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package button
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+
+	// Register the image formats supported by NewImageFromReader.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/matthewpi/streamdeck"
+)
+
+// NewImageFromReader returns a new static Button displaying an image decoded
+// from r.
+//
+// PNG, JPEG, BMP, and GIF images are supported, only the first frame of an
+// animated GIF will be displayed. Use NewGIF, NewAPNG, or NewWebP to display
+// an animated image.
+func NewImageFromReader(sd *streamdeck.StreamDeck, r io.Reader) (*Image, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("button: failed to decode image: %w", err)
+	}
+
+	rawImage, err := sd.ProcessImage(img)
+	if err != nil {
+		return nil, err
+	}
+	return NewImage(rawImage), nil
+}
+
+// NewImageFromFile returns a new static Button displaying the image stored in
+// the file at path, see NewImageFromReader for the supported formats.
+func NewImageFromFile(sd *streamdeck.StreamDeck, path string) (*Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewImageFromReader(sd, f)
+}
@@ -0,0 +1,169 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package button
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/disintegration/gift"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/matthewpi/streamdeck"
+)
+
+// LabelPosition represents where a label is displayed on a Button.
+type LabelPosition uint8
+
+const (
+	// LabelBottom displays the label at the bottom of the Button.
+	LabelBottom LabelPosition = iota
+	// LabelTop displays the label at the top of the Button.
+	LabelTop
+)
+
+// IconTextOptions are options used to render an icon with a text label.
+type IconTextOptions struct {
+	// LabelPosition is the position of the label on the Button, defaults to
+	// LabelBottom.
+	LabelPosition LabelPosition
+
+	// FontSize is the size of the label's font in pixels, if zero a size
+	// relative to the Device's image size will be used.
+	FontSize float64
+
+	// Padding is the amount of space in pixels around the icon and label.
+	Padding int
+
+	// TextColor is the color of the label, defaults to white.
+	TextColor color.Color
+
+	// BackgroundColor is the color of the bar behind the label, defaults to
+	// black.
+	BackgroundColor color.Color
+
+	// Font is the font used to render the label, defaults to Go Regular.
+	//
+	// Any TrueType or OpenType font with outline glyphs may be used, such as a
+	// CJK or monochrome emoji font. Fonts that only contain color bitmap
+	// glyphs are not supported.
+	Font *opentype.Font
+
+	// FallbackFonts are used, in order, to render any characters that are
+	// missing from Font. Characters missing from every font are drawn as a
+	// replacement box.
+	FallbackFonts []*opentype.Font
+}
+
+var (
+	// fontOnce is used to parse the label font only once.
+	fontOnce sync.Once
+	// labelFont is the font used to render labels.
+	labelFont *opentype.Font
+	// labelFontErr is the error returned when parsing the label font.
+	labelFontErr error
+)
+
+// loadFont parses the font used to render labels.
+func loadFont() (*opentype.Font, error) {
+	fontOnce.Do(func() {
+		labelFont, labelFontErr = opentype.Parse(goregular.TTF)
+	})
+	return labelFont, labelFontErr
+}
+
+// NewIconText returns a new static Button displaying an icon with a text label.
+//
+// The icon is scaled to fit the space that isn't used by the label, the label
+// is drawn on top of a background bar.
+func NewIconText(sd *streamdeck.StreamDeck, icon image.Image, label string, opts IconTextOptions) (*Image, error) {
+	size := sd.Device().ImageDimensions()
+	if opts.FontSize <= 0 {
+		opts.FontSize = float64(size.Y) / 6
+	}
+	if opts.TextColor == nil {
+		opts.TextColor = color.White
+	}
+	if opts.BackgroundColor == nil {
+		opts.BackgroundColor = color.Black
+	}
+
+	face, err := newTextFace(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	// Calculate the area used by the label and the area left for the icon.
+	metrics := face.Metrics()
+	labelHeight := metrics.Height.Ceil() + opts.Padding*2
+	labelRect := image.Rect(0, size.Y-labelHeight, size.X, size.Y)
+	iconRect := image.Rect(0, 0, size.X, size.Y-labelHeight)
+	if opts.LabelPosition == LabelTop {
+		labelRect = image.Rect(0, 0, size.X, labelHeight)
+		iconRect = image.Rect(0, labelHeight, size.X, size.Y)
+	}
+	iconRect = iconRect.Inset(opts.Padding)
+
+	img := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	// Scale the icon to fit its area and center it.
+	if icon != nil && !iconRect.Empty() {
+		g := gift.New(gift.ResizeToFit(iconRect.Dx(), iconRect.Dy(), gift.LanczosResampling))
+		bounds := g.Bounds(icon.Bounds())
+		offset := image.Point{
+			X: iconRect.Min.X + (iconRect.Dx()-bounds.Dx())/2,
+			Y: iconRect.Min.Y + (iconRect.Dy()-bounds.Dy())/2,
+		}
+		scaled := image.NewRGBA(bounds)
+		g.Draw(scaled, icon)
+		streamdeck.CompositeOver(img, bounds.Add(offset), scaled, image.Point{})
+	}
+
+	// Draw the label's background bar and center the label on top of it, the
+	// bar is blended in linear light so a semi-transparent bar doesn't darken
+	// the icon more than expected.
+	streamdeck.CompositeOver(img, labelRect, image.NewUniform(opts.BackgroundColor), image.Point{})
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(opts.TextColor),
+		Face: face,
+	}
+	d.Dot = fixed.Point26_6{
+		X: fixed.I(labelRect.Min.X) + (fixed.I(labelRect.Dx())-d.MeasureString(label))/2,
+		Y: fixed.I(labelRect.Min.Y+opts.Padding) + metrics.Ascent,
+	}
+	d.DrawString(label)
+
+	rawImage, err := sd.ProcessImage(img)
+	if err != nil {
+		return nil, err
+	}
+	return NewImage(rawImage), nil
+}
@@ -0,0 +1,79 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package button
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// KeyDown represents a Button that displays a different image while it is held
+// down, like the pressed state used by the official Stream Deck software.
+//
+// When used with a Buttons View, the image is swapped automatically when the
+// Button is pressed and released.
+type KeyDown struct {
+	normal  []byte
+	pressed []byte
+
+	held atomic.Bool
+}
+
+var (
+	_ Button     = (*KeyDown)(nil)
+	_ Pressable  = (*KeyDown)(nil)
+	_ Releasable = (*KeyDown)(nil)
+)
+
+// NewPressable returns a new Button displaying normal, or pressed while the
+// Button is held down. Both images must be processed by
+// StreamDeck#ProcessImage.
+//
+// The returned type is named KeyDown as Pressable is the interface implemented
+// by Buttons that handle their own press events.
+func NewPressable(normal, pressed []byte) *KeyDown {
+	return &KeyDown{
+		normal:  normal,
+		pressed: pressed,
+	}
+}
+
+// Image satisfies the Button interface.
+func (k *KeyDown) Image() []byte {
+	if k.held.Load() {
+		return k.pressed
+	}
+	return k.normal
+}
+
+// OnPress satisfies the Pressable interface.
+func (k *KeyDown) OnPress(context.Context) error {
+	k.held.Store(true)
+	return nil
+}
+
+// OnRelease satisfies the Releasable interface.
+func (k *KeyDown) OnRelease(context.Context) error {
+	k.held.Store(false)
+	return nil
+}
@@ -0,0 +1,168 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package button
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+
+	"github.com/matthewpi/streamdeck"
+)
+
+// ProgressOrientation represents how a progress bar is drawn on a Button.
+type ProgressOrientation uint8
+
+const (
+	// ProgressHorizontal fills the Button from left to right.
+	ProgressHorizontal ProgressOrientation = iota
+	// ProgressVertical fills the Button from bottom to top.
+	ProgressVertical
+	// ProgressRadial fills a ring clockwise, starting from the top of the
+	// Button.
+	ProgressRadial
+)
+
+// ProgressOptions are options used to render a progress bar.
+type ProgressOptions struct {
+	// Orientation of the progress bar, defaults to ProgressHorizontal.
+	Orientation ProgressOrientation
+
+	// FillColor is the color of the completed part of the progress bar,
+	// defaults to white.
+	FillColor color.Color
+
+	// BackgroundColor is the color of the rest of the Button, defaults to
+	// black.
+	BackgroundColor color.Color
+}
+
+// Progress represents a Button displaying a progress bar.
+type Progress struct {
+	sd   *streamdeck.StreamDeck
+	opts ProgressOptions
+
+	progressMx sync.Mutex
+	progress   float64
+	img        []byte
+}
+
+var _ Button = (*Progress)(nil)
+
+// NewProgress returns a new Button displaying a progress bar, the progress bar
+// will start empty.
+func NewProgress(sd *streamdeck.StreamDeck, opts ProgressOptions) (*Progress, error) {
+	if opts.FillColor == nil {
+		opts.FillColor = color.White
+	}
+	if opts.BackgroundColor == nil {
+		opts.BackgroundColor = color.Black
+	}
+
+	p := &Progress{sd: sd, opts: opts}
+	if _, err := p.SetProgress(0); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Image satisfies the Button interface.
+func (p *Progress) Image() []byte {
+	p.progressMx.Lock()
+	defer p.progressMx.Unlock()
+	return p.img
+}
+
+// Progress returns the current progress in the range [0, 1].
+func (p *Progress) Progress() float64 {
+	p.progressMx.Lock()
+	defer p.progressMx.Unlock()
+	return p.progress
+}
+
+// SetProgress sets the progress displayed by the Button, progress is a fraction
+// in the range [0, 1] and will be clamped to that range. The updated image is
+// returned so it can be displayed, like by using Buttons#Update.
+//
+// This method is safe to call concurrently.
+func (p *Progress) SetProgress(progress float64) ([]byte, error) {
+	if progress < 0 || math.IsNaN(progress) {
+		progress = 0
+	}
+	if progress > 1 {
+		progress = 1
+	}
+
+	p.progressMx.Lock()
+	defer p.progressMx.Unlock()
+
+	rawImage, err := p.sd.ProcessImage(p.render(progress))
+	if err != nil {
+		return nil, err
+	}
+	p.progress = progress
+	p.img = rawImage
+	return rawImage, nil
+}
+
+// render draws the progress bar.
+func (p *Progress) render(progress float64) image.Image {
+	size := p.sd.Device().ImageDimensions()
+	img := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	draw.Draw(img, img.Bounds(), image.NewUniform(p.opts.BackgroundColor), image.Point{}, draw.Src)
+
+	fill := image.NewUniform(p.opts.FillColor)
+	switch p.opts.Orientation {
+	case ProgressVertical:
+		height := int(math.Round(progress * float64(size.Y)))
+		draw.Draw(img, image.Rect(0, size.Y-height, size.X, size.Y), fill, image.Point{}, draw.Src)
+	case ProgressRadial:
+		cx, cy := float64(size.X)/2, float64(size.Y)/2
+		outer := math.Min(cx, cy) * 0.9
+		inner := outer * 0.7
+		c := p.opts.FillColor
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+				if dist := math.Hypot(dx, dy); dist < inner || dist > outer {
+					continue
+				}
+				// The angle of the pixel clockwise from the top, in the range
+				// [0, 1).
+				angle := math.Atan2(dx, -dy) / (2 * math.Pi)
+				if angle < 0 {
+					angle++
+				}
+				if angle < progress {
+					img.Set(x, y, c)
+				}
+			}
+		}
+	default:
+		width := int(math.Round(progress * float64(size.X)))
+		draw.Draw(img, image.Rect(0, 0, width, size.Y), fill, image.Point{}, draw.Src)
+	}
+	return img
+}
@@ -0,0 +1,231 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package button
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/matthewpi/streamdeck"
+)
+
+// NewText returns a new static Button displaying text, each line of the text
+// is centered on the Button.
+//
+// The text is rendered using the same options as NewIconText, the
+// LabelPosition is ignored and the BackgroundColor fills the entire Button.
+func NewText(sd *streamdeck.StreamDeck, text string, opts IconTextOptions) (*Image, error) {
+	img, err := RenderText(sd.Device().ImageDimensions(), text, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rawImage, err := sd.ProcessImage(img)
+	if err != nil {
+		return nil, err
+	}
+	return NewImage(rawImage), nil
+}
+
+// RenderText renders text onto an image of the given size using the same
+// layout as NewText, without processing the image for a Stream Deck.
+//
+// This is useful to render text for other displays, like the touchscreen of a
+// Stream Deck Plus.
+func RenderText(size image.Point, text string, opts IconTextOptions) (image.Image, error) {
+	if opts.FontSize <= 0 {
+		opts.FontSize = float64(size.Y) / 6
+	}
+	if opts.TextColor == nil {
+		opts.TextColor = color.White
+	}
+	if opts.BackgroundColor == nil {
+		opts.BackgroundColor = color.Black
+	}
+
+	face, err := newTextFace(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	img := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	draw.Draw(img, img.Bounds(), image.NewUniform(opts.BackgroundColor), image.Point{}, draw.Src)
+
+	// Center the block of lines vertically, then each line horizontally.
+	metrics := face.Metrics()
+	lines := strings.Split(text, "\n")
+	height := metrics.Height.Mul(fixed.I(len(lines)))
+	y := (fixed.I(size.Y)-height)/2 + metrics.Ascent
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(opts.TextColor),
+		Face: face,
+	}
+	for _, line := range lines {
+		d.Dot = fixed.Point26_6{
+			X: (fixed.I(size.X) - d.MeasureString(line)) / 2,
+			Y: y,
+		}
+		d.DrawString(line)
+		y += metrics.Height
+	}
+	return img, nil
+}
+
+// textFace is a font.Face that renders each character using the first font
+// that contains it, characters missing from every font are drawn as a
+// replacement box.
+type textFace struct {
+	fonts []*opentype.Font
+	faces []font.Face
+	buf   sfnt.Buffer
+}
+
+var _ font.Face = (*textFace)(nil)
+
+// newTextFace returns the face used to render text using the font size and
+// fonts specified by the options.
+func newTextFace(opts IconTextOptions) (*textFace, error) {
+	primary := opts.Font
+	if primary == nil {
+		f, err := loadFont()
+		if err != nil {
+			return nil, err
+		}
+		primary = f
+	}
+
+	t := &textFace{}
+	for _, f := range append([]*opentype.Font{primary}, opts.FallbackFonts...) {
+		if f == nil {
+			continue
+		}
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{
+			Size:    opts.FontSize,
+			DPI:     72,
+			Hinting: font.HintingFull,
+		})
+		if err != nil {
+			_ = t.Close()
+			return nil, err
+		}
+		t.fonts = append(t.fonts, f)
+		t.faces = append(t.faces, face)
+	}
+	return t, nil
+}
+
+// face returns the face used to render a character, ok will be false if the
+// character is missing from every font.
+func (t *textFace) face(r rune) (face font.Face, ok bool) {
+	for i, f := range t.fonts {
+		if index, err := f.GlyphIndex(&t.buf, r); err == nil && index != 0 {
+			return t.faces[i], true
+		}
+	}
+	return t.faces[0], false
+}
+
+// box returns the size of the replacement box and the advance used for it.
+func (t *textFace) box() (size image.Point, advance fixed.Int26_6) {
+	ascent := t.faces[0].Metrics().Ascent.Ceil()
+	size = image.Pt(ascent*3/5, ascent*3/4)
+	return size, fixed.I(size.X + 2)
+}
+
+// Close satisfies the font.Face interface.
+func (t *textFace) Close() error {
+	var err error
+	for _, face := range t.faces {
+		if v := face.Close(); v != nil && err == nil {
+			err = v
+		}
+	}
+	return err
+}
+
+// Glyph satisfies the font.Face interface.
+func (t *textFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	if face, ok := t.face(r); ok {
+		return face.Glyph(dot, r)
+	}
+
+	// Draw the outline of a box in place of the missing character.
+	size, advance := t.box()
+	mask := image.NewAlpha(image.Rect(0, 0, size.X, size.Y))
+	thickness := size.Y/12 + 1
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			if x < thickness || y < thickness || x >= size.X-thickness || y >= size.Y-thickness {
+				mask.SetAlpha(x, y, color.Alpha{A: 0xff})
+			}
+		}
+	}
+	min := image.Pt(dot.X.Round()+1, dot.Y.Round()-size.Y)
+	return image.Rectangle{Min: min, Max: min.Add(size)}, mask, image.Point{}, advance, true
+}
+
+// GlyphBounds satisfies the font.Face interface.
+func (t *textFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	if face, ok := t.face(r); ok {
+		return face.GlyphBounds(r)
+	}
+	size, advance := t.box()
+	bounds := fixed.Rectangle26_6{
+		Min: fixed.Point26_6{X: fixed.I(1), Y: -fixed.I(size.Y)},
+		Max: fixed.Point26_6{X: fixed.I(1 + size.X), Y: 0},
+	}
+	return bounds, advance, true
+}
+
+// GlyphAdvance satisfies the font.Face interface.
+func (t *textFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	if face, ok := t.face(r); ok {
+		return face.GlyphAdvance(r)
+	}
+	_, advance := t.box()
+	return advance, true
+}
+
+// Kern satisfies the font.Face interface.
+func (t *textFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	face0, ok0 := t.face(r0)
+	face1, ok1 := t.face(r1)
+	if !ok0 || !ok1 || face0 != face1 {
+		return 0
+	}
+	return face0.Kern(r0, r1)
+}
+
+// Metrics satisfies the font.Face interface.
+func (t *textFace) Metrics() font.Metrics {
+	return t.faces[0].Metrics()
+}
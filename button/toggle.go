@@ -0,0 +1,94 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package button
+
+import (
+	"context"
+	"sync"
+)
+
+// Toggle represents a Button that displays a different image depending on
+// whether it is on or off.
+//
+// When used with a Buttons View, pressing the Button will toggle its state and
+// update the image displayed.
+type Toggle struct {
+	on  []byte
+	off []byte
+
+	stateMx sync.Mutex
+	state   bool
+}
+
+var (
+	_ Button    = (*Toggle)(nil)
+	_ Pressable = (*Toggle)(nil)
+)
+
+// NewToggle returns a new Button that toggles between two images, the Button
+// will start in the off state.
+func NewToggle(on, off []byte) *Toggle {
+	return &Toggle{on: on, off: off}
+}
+
+// Image satisfies the Button interface.
+func (t *Toggle) Image() []byte {
+	t.stateMx.Lock()
+	defer t.stateMx.Unlock()
+	if t.state {
+		return t.on
+	}
+	return t.off
+}
+
+// State returns true if the Button is on.
+func (t *Toggle) State() bool {
+	t.stateMx.Lock()
+	defer t.stateMx.Unlock()
+	return t.state
+}
+
+// SetState sets whether the Button is on or off.
+//
+// This method is safe to call concurrently.
+func (t *Toggle) SetState(state bool) {
+	t.stateMx.Lock()
+	t.state = state
+	t.stateMx.Unlock()
+}
+
+// Toggle toggles the state of the Button, returning the new state.
+//
+// This method is safe to call concurrently.
+func (t *Toggle) Toggle() bool {
+	t.stateMx.Lock()
+	defer t.stateMx.Unlock()
+	t.state = !t.state
+	return t.state
+}
+
+// OnPress satisfies the Pressable interface.
+func (t *Toggle) OnPress(context.Context) error {
+	t.Toggle()
+	return nil
+}
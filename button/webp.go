@@ -0,0 +1,246 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package button
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"time"
+
+	"golang.org/x/image/webp"
+
+	"github.com/matthewpi/streamdeck"
+)
+
+// WebP VP8X and ANMF flags.
+const (
+	webpFlagAnimation = 0x02
+	webpFlagAlpha     = 0x10
+
+	webpFrameDispose = 0x01
+	webpFrameNoBlend = 0x02
+)
+
+// NewWebP returns a new animated Button that displays an animated WebP decoded
+// from r.
+//
+// A WebP without any animation is displayed as a static image.
+func NewWebP(sd *streamdeck.StreamDeck, r io.Reader) (*Animation, error) {
+	frames, delays, err := decodeWebP(r)
+	if err != nil {
+		return nil, fmt.Errorf("button: failed to decode webp: %w", err)
+	}
+	return NewAnimated(sd, frames, delays)
+}
+
+// webpChunk is a chunk of a WebP file.
+type webpChunk struct {
+	typ  string
+	data []byte
+}
+
+// decodeWebP decodes the frames of an animated WebP, compositing them onto the
+// canvas so every frame is a complete image.
+//
+// golang.org/x/image/webp can't decode animated WebPs, so every frame is
+// decoded by rewriting it as a standalone WebP.
+func decodeWebP(r io.Reader) ([]image.Image, []time.Duration, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WEBP" {
+		return nil, nil, errors.New("not a webp file")
+	}
+	chunks, err := readWebPChunks(b[12:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A WebP without the animation flag is a static image.
+	if len(chunks) == 0 || chunks[0].typ != "VP8X" || len(chunks[0].data) != 10 || chunks[0].data[0]&webpFlagAnimation == 0 {
+		cfg, err := webp.DecodeConfig(bytes.NewReader(b))
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := checkCanvas(image.Rect(0, 0, cfg.Width, cfg.Height), 1); err != nil {
+			return nil, nil, err
+		}
+		img, err := webp.Decode(bytes.NewReader(b))
+		if err != nil {
+			return nil, nil, err
+		}
+		return []image.Image{img}, []time.Duration{defaultFrameDelay}, nil
+	}
+	vp8x := chunks[0].data
+	canvas := image.Rect(0, 0, int(uint24(vp8x[4:7]))+1, int(uint24(vp8x[7:10]))+1)
+	var n int
+	for _, chunk := range chunks[1:] {
+		if chunk.typ == "ANMF" {
+			n++
+		}
+	}
+	if err := checkCanvas(canvas, n); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		c      = newCompositor(canvas)
+		frames []image.Image
+		delays []time.Duration
+	)
+	for _, chunk := range chunks[1:] {
+		if chunk.typ != "ANMF" {
+			continue
+		}
+		i := len(frames)
+		if len(chunk.data) < 16 {
+			return nil, nil, fmt.Errorf("frame %d: malformed ANMF chunk", i)
+		}
+		var (
+			x      = int(uint24(chunk.data[0:3])) * 2
+			y      = int(uint24(chunk.data[3:6])) * 2
+			width  = int(uint24(chunk.data[6:9])) + 1
+			height = int(uint24(chunk.data[9:12])) + 1
+			delay  = time.Duration(uint24(chunk.data[12:15])) * time.Millisecond
+			flags  = chunk.data[15]
+		)
+		bounds := image.Rect(x, y, x+width, y+height)
+		if !bounds.In(canvas) {
+			return nil, nil, fmt.Errorf("frame %d is outside of the canvas", i)
+		}
+
+		frame, err := readWebPChunks(chunk.data[16:])
+		if err != nil {
+			return nil, nil, fmt.Errorf("frame %d: %w", i, err)
+		}
+		img, err := webp.Decode(bytes.NewReader(encodeWebPFrame(width, height, frame)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode frame %d: %w", i, err)
+		}
+		if img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+			return nil, nil, fmt.Errorf("frame %d does not match its size", i)
+		}
+
+		op := draw.Over
+		if flags&webpFrameNoBlend != 0 {
+			op = draw.Src
+		}
+		disposal := disposeNone
+		if flags&webpFrameDispose != 0 {
+			disposal = disposeBackground
+		}
+		frames = append(frames, c.draw(bounds, img, op, disposal))
+		delays = append(delays, frameDelay(delay))
+	}
+	if len(frames) == 0 {
+		return nil, nil, errors.New("webp has no frames")
+	}
+	return frames, delays, nil
+}
+
+// uint24 decodes a little-endian 24-bit integer.
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+// readWebPChunks reads the chunks of a RIFF container.
+func readWebPChunks(b []byte) ([]webpChunk, error) {
+	var chunks []webpChunk
+	for len(b) > 0 {
+		if len(b) < 8 {
+			return nil, errors.New("short chunk header")
+		}
+		length := binary.LittleEndian.Uint32(b[4:8])
+		if uint64(length) > uint64(len(b)-8) {
+			return nil, errors.New("chunk too large")
+		}
+		chunks = append(chunks, webpChunk{typ: string(b[0:4]), data: b[8 : 8+length]})
+
+		// Chunks are padded to an even length.
+		next := 8 + int(length) + int(length&1)
+		if next > len(b) {
+			next = len(b)
+		}
+		b = b[next:]
+	}
+	return chunks, nil
+}
+
+// encodeWebPFrame returns a standalone WebP file made up of the image chunks
+// of a frame of an animated WebP.
+func encodeWebPFrame(width, height int, frame []webpChunk) []byte {
+	var alph, data *webpChunk
+	for i, c := range frame {
+		switch c.typ {
+		case "ALPH":
+			alph = &frame[i]
+		case "VP8 ", "VP8L":
+			data = &frame[i]
+		}
+	}
+
+	var chunks bytes.Buffer
+	// Lossy frames store their alpha channel separately, which requires an
+	// extended header.
+	if alph != nil && data != nil && data.typ == "VP8 " {
+		vp8x := make([]byte, 10)
+		vp8x[0] = webpFlagAlpha
+		putUint24(vp8x[4:7], uint32(width-1))
+		putUint24(vp8x[7:10], uint32(height-1))
+		writeWebPChunk(&chunks, "VP8X", vp8x)
+		writeWebPChunk(&chunks, alph.typ, alph.data)
+	}
+	if data != nil {
+		writeWebPChunk(&chunks, data.typ, data.data)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(4+chunks.Len()))
+	b.WriteString("WEBP")
+	b.Write(chunks.Bytes())
+	return b.Bytes()
+}
+
+// putUint24 encodes a little-endian 24-bit integer.
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
+
+// writeWebPChunk writes a chunk of a RIFF container, padded to an even length.
+func writeWebPChunk(b *bytes.Buffer, typ string, data []byte) {
+	b.WriteString(typ)
+	_ = binary.Write(b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+	if len(data)%2 == 1 {
+		b.WriteByte(0)
+	}
+}
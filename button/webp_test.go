@@ -0,0 +1,253 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package button
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/image/webp"
+)
+
+// bitWriter writes the least significant bits first, as used by VP8L.
+type bitWriter struct {
+	b    []byte
+	nBit uint
+}
+
+func (w *bitWriter) write(v uint32, n uint) {
+	for i := uint(0); i < n; i++ {
+		if w.nBit%8 == 0 {
+			w.b = append(w.b, 0)
+		}
+		w.b[len(w.b)-1] |= byte(v>>i&1) << (w.nBit % 8)
+		w.nBit++
+	}
+}
+
+// solidVP8L returns a lossless WebP bitstream of an image filled with c.
+//
+// Every prefix code only has a single symbol, so the pixels themselves take
+// zero bits to encode.
+func solidVP8L(width, height int, c color.NRGBA) []byte {
+	w := &bitWriter{}
+	w.write(0x2f, 8)
+	w.write(uint32(width-1), 14)
+	w.write(uint32(height-1), 14)
+	w.write(1, 1) // Alpha is used.
+	w.write(0, 3) // Version.
+	w.write(0, 1) // No transforms.
+	w.write(0, 1) // No colour cache.
+	w.write(0, 1) // No meta prefix codes.
+	// The green, red, blue, alpha, and distance prefix codes.
+	for _, v := range []uint8{c.G, c.R, c.B, c.A, 0} {
+		w.write(1, 1) // Simple code.
+		w.write(0, 1) // A single symbol.
+		w.write(1, 1) // The symbol is 8 bits.
+		w.write(uint32(v), 8)
+	}
+	return w.b
+}
+
+// testWebPFrame is a frame of an animated WebP built by encodeTestWebP.
+type testWebPFrame struct {
+	bounds image.Rectangle
+	c      color.NRGBA
+	delay  int
+	flags  byte
+}
+
+// encodeTestWebP returns an animated WebP with a 4x4 canvas made up of frames
+// filled with a single colour.
+func encodeTestWebP(frames []testWebPFrame) []byte {
+	var chunks bytes.Buffer
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagAnimation | webpFlagAlpha
+	putUint24(vp8x[4:7], 3)
+	putUint24(vp8x[7:10], 3)
+	writeWebPChunk(&chunks, "VP8X", vp8x)
+	writeWebPChunk(&chunks, "ANIM", make([]byte, 6))
+
+	for _, f := range frames {
+		var anmf bytes.Buffer
+		hdr := make([]byte, 16)
+		putUint24(hdr[0:3], uint32(f.bounds.Min.X/2))
+		putUint24(hdr[3:6], uint32(f.bounds.Min.Y/2))
+		putUint24(hdr[6:9], uint32(f.bounds.Dx()-1))
+		putUint24(hdr[9:12], uint32(f.bounds.Dy()-1))
+		putUint24(hdr[12:15], uint32(f.delay))
+		hdr[15] = f.flags
+		anmf.Write(hdr)
+		writeWebPChunk(&anmf, "VP8L", solidVP8L(f.bounds.Dx(), f.bounds.Dy(), f.c))
+		writeWebPChunk(&chunks, "ANMF", anmf.Bytes())
+	}
+
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(4+chunks.Len()))
+	b.WriteString("WEBP")
+	b.Write(chunks.Bytes())
+	return b.Bytes()
+}
+
+func TestSolidVP8L(t *testing.T) {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	data := solidVP8L(3, 2, color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff})
+	_ = binary.Write(&b, binary.LittleEndian, uint32(4+8+len(data)+len(data)%2))
+	b.WriteString("WEBP")
+	writeWebPChunk(&b, "VP8L", data)
+
+	img, err := webp.Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 3, 2) {
+		t.Fatalf("unexpected bounds %v", img.Bounds())
+	}
+	if got := color.NRGBAModel.Convert(img.At(2, 1)); got != (color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff}) {
+		t.Errorf("unexpected colour %v", got)
+	}
+}
+
+func TestDecodeWebP(t *testing.T) {
+	nred := color.NRGBA{R: 0xff, A: 0xff}
+	nblue := color.NRGBA{B: 0xff, A: 0xff}
+	for _, tc := range []struct {
+		name  string
+		flags byte
+		// centre is the colour of the bottom right quarter in the third frame.
+		centre color.RGBA
+	}{
+		{name: "blend", centre: over(half, blue)},
+		{name: "dispose", flags: webpFrameDispose, centre: over(half, transparent)},
+		{name: "no blend", flags: webpFrameNoBlend, centre: over(half, transparent)},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			b := encodeTestWebP([]testWebPFrame{
+				{bounds: image.Rect(0, 0, 4, 4), c: nred, delay: 100},
+				{bounds: image.Rect(2, 2, 4, 4), c: nblue, delay: 500, flags: tc.flags & webpFrameDispose},
+				{bounds: image.Rect(2, 2, 4, 4), c: half, delay: 0, flags: tc.flags & webpFrameNoBlend},
+			})
+			frames, delays, err := decodeWebP(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(frames) != 3 {
+				t.Fatalf("expected 3 frames, got %d", len(frames))
+			}
+
+			wantDelays := []time.Duration{100 * time.Millisecond, 500 * time.Millisecond, defaultFrameDelay}
+			for i, d := range delays {
+				if d != wantDelays[i] {
+					t.Errorf("frame %d: expected a delay of %v, got %v", i, wantDelays[i], d)
+				}
+			}
+
+			checkPixels(t, 0, frames[0], map[image.Point]color.RGBA{{0, 0}: red, {3, 3}: red})
+			checkPixels(t, 1, frames[1], map[image.Point]color.RGBA{{0, 0}: red, {3, 3}: blue})
+			checkPixels(t, 2, frames[2], map[image.Point]color.RGBA{{0, 0}: red, {3, 3}: tc.centre})
+		})
+	}
+}
+
+func TestDecodeWebPStatic(t *testing.T) {
+	var chunks bytes.Buffer
+	writeWebPChunk(&chunks, "VP8L", solidVP8L(2, 2, color.NRGBA{B: 0xff, A: 0xff}))
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(4+chunks.Len()))
+	b.WriteString("WEBP")
+	b.Write(chunks.Bytes())
+
+	frames, delays, err := decodeWebP(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 1 || len(delays) != 1 {
+		t.Fatalf("expected a single frame, got %d", len(frames))
+	}
+	if got := color.RGBAModel.Convert(frames[0].At(1, 1)); got != blue {
+		t.Errorf("expected %v, got %v", blue, got)
+	}
+}
+
+func TestDecodeWebPMalformed(t *testing.T) {
+	b := encodeTestWebP([]testWebPFrame{
+		{bounds: image.Rect(0, 0, 4, 4), c: color.NRGBA{R: 0xff, A: 0xff}},
+		{bounds: image.Rect(2, 2, 6, 6), c: color.NRGBA{B: 0xff, A: 0xff}},
+	})
+	if _, _, err := decodeWebP(bytes.NewReader(b)); err == nil {
+		t.Error("expected a frame outside of the canvas to be rejected")
+	}
+
+	b = encodeTestWebP([]testWebPFrame{{bounds: image.Rect(0, 0, 4, 4), c: color.NRGBA{R: 0xff, A: 0xff}}})
+	if _, _, err := decodeWebP(bytes.NewReader(b[:len(b)-4])); err == nil {
+		t.Error("expected a truncated file to be rejected")
+	}
+}
+
+// craftedWebP returns an animated WebP with the given canvas size and n empty
+// frames.
+func craftedWebP(width, height uint32, n int) []byte {
+	var chunks bytes.Buffer
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagAnimation
+	putUint24(vp8x[4:7], width-1)
+	putUint24(vp8x[7:10], height-1)
+	writeWebPChunk(&chunks, "VP8X", vp8x)
+	for i := 0; i < n; i++ {
+		writeWebPChunk(&chunks, "ANMF", make([]byte, 16))
+	}
+
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(4+chunks.Len()))
+	b.WriteString("WEBP")
+	b.Write(chunks.Bytes())
+	return b.Bytes()
+}
+
+func TestDecodeWebPTooLarge(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		b    []byte
+	}{
+		{name: "huge canvas", b: craftedWebP(1<<24, 1<<24, 1)},
+		{name: "too many frames", b: craftedWebP(2048, 2048, 17)},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := decodeWebP(bytes.NewReader(tc.b))
+			if err == nil || !strings.Contains(err.Error(), "too large") {
+				t.Errorf("expected the canvas to be too large, got %v", err)
+			}
+		})
+	}
+}
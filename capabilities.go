@@ -0,0 +1,101 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package streamdeck
+
+import (
+	"context"
+	"encoding/binary"
+	"image"
+)
+
+// Capabilities describes the physical layout of a Device.
+type Capabilities struct {
+	// Keys is the number of keys on the Device.
+	Keys int
+
+	// KeyResolution is the resolution of the display on each key, or zero if
+	// the keys don't have a display.
+	KeyResolution image.Point
+
+	// Dials is the number of rotary dials on the Device.
+	Dials int
+
+	// Touchscreen is the resolution of the touchscreen on the Device, or zero
+	// if the Device doesn't have a touchscreen.
+	Touchscreen image.Point
+
+	// Reported is true if the number of keys was reported by the Device, rather
+	// than taken from the DeviceType.
+	Reported bool
+}
+
+// Capabilities returns the physical layout of the Device.
+//
+// Stream Decks do not report the resolution of their keys, or whether they
+// have dials or a touchscreen. Only newer generations of Devices report their
+// number of keys, in the header of their input reports. Any values that aren't
+// reported by the Device are taken from the DeviceType.
+func (d *Device) Capabilities(ctx context.Context) Capabilities {
+	c := Capabilities{
+		Keys:        d.ButtonCount(),
+		Dials:       d.Dials,
+		Touchscreen: d.Touchscreen,
+	}
+	if d.HasDisplay() {
+		c.KeyResolution = d.ImageDimensions()
+	}
+
+	if keys, ok := d.reportedKeys(ctx); ok {
+		c.Keys = keys
+		c.Reported = true
+	}
+	return c
+}
+
+// reportedKeys reads the number of keys reported by the Device, ok will be
+// false if the Device doesn't report its number of keys.
+func (d *Device) reportedKeys(ctx context.Context) (keys int, ok bool) {
+	// Only Devices using a four byte header for their input reports include
+	// the number of keys in the header.
+	if d.ButtonOffset != 4 {
+		return 0, false
+	}
+
+	b := make([]byte, d.readSize())
+	b[0] = 0x01
+	n, err := d.fd.GetInputReport(ctx, b)
+	if err != nil || n < 4 {
+		return 0, false
+	}
+
+	// The second byte of the header is the type of the input report, a value
+	// of zero is used for key states.
+	if b[1] != 0x00 {
+		return 0, false
+	}
+	keys = int(binary.LittleEndian.Uint16(b[2:4]))
+	if keys == 0 || keys > n-4 {
+		return 0, false
+	}
+	return keys, true
+}
@@ -0,0 +1,142 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+)
+
+// linearLUTSize is the number of entries in the table used to convert linear
+// values back to sRGB, a larger table increases precision for dark colors.
+const linearLUTSize = 4096
+
+var (
+	linearLUTOnce sync.Once
+	// toLinearLUT maps an 8-bit sRGB value to its linear value.
+	toLinearLUT [256]float64
+	// fromLinearLUT maps a linear value, scaled to linearLUTSize, to its 16-bit
+	// sRGB value.
+	fromLinearLUT [linearLUTSize + 1]uint16
+)
+
+// initLinearLUT initializes the tables used to convert between sRGB and linear
+// values.
+func initLinearLUT() {
+	for i := range toLinearLUT {
+		toLinearLUT[i] = srgbToLinear(float64(i) / 255)
+	}
+	for i := range fromLinearLUT {
+		fromLinearLUT[i] = uint16(math.Round(linearToSRGB(float64(i)/linearLUTSize) * 0xffff))
+	}
+}
+
+// srgbToLinear converts an sRGB value in the range [0, 1] to linear light.
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts a linear value in the range [0, 1] to sRGB.
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// toLinear converts a non-premultiplied 16-bit sRGB value to linear light.
+func toLinear(v uint32) float64 {
+	return toLinearLUT[v>>8]
+}
+
+// fromLinear converts a linear value in the range [0, 1] to a 16-bit sRGB
+// value.
+func fromLinear(v float64) uint32 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return 0xffff
+	}
+	return uint32(fromLinearLUT[int(v*linearLUTSize+0.5)])
+}
+
+// CompositeOver draws src over dst like draw.Draw with draw.Over, except the
+// colors are blended in linear light rather than sRGB.
+//
+// Blending in sRGB makes semi-transparent overlays, like a label's background
+// bar or the edges of an icon, appear darker than they should. r is the area
+// of dst to draw to and sp is the point in src aligned with r.Min.
+func CompositeOver(dst draw.Image, r image.Rectangle, src image.Image, sp image.Point) {
+	linearLUTOnce.Do(initLinearLUT)
+
+	// Clip the rectangle to the bounds of both images, the same as draw.Draw.
+	orig := r.Min
+	r = r.Intersect(dst.Bounds()).Intersect(src.Bounds().Add(orig.Sub(sp)))
+	sp = sp.Add(r.Min.Sub(orig))
+	if r.Empty() {
+		return
+	}
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			sx, sy := sp.X+x-r.Min.X, sp.Y+y-r.Min.Y
+			sr, sg, sb, sa := src.At(sx, sy).RGBA()
+			if sa == 0 {
+				continue
+			}
+			if sa == 0xffff {
+				dst.Set(x, y, color.RGBA64{R: uint16(sr), G: uint16(sg), B: uint16(sb), A: 0xffff})
+				continue
+			}
+			dr, dg, db, da := dst.At(x, y).RGBA()
+
+			srcA := float64(sa) / 0xffff
+			dstA := float64(da) / 0xffff
+			outA := srcA + dstA*(1-srcA)
+			blend := func(s, d uint32) uint16 {
+				// Un-premultiply the colors before converting them to linear
+				// light, then re-premultiply the result.
+				var sl, dl float64
+				sl = toLinear(s * 0xffff / sa)
+				if da > 0 {
+					dl = toLinear(d * 0xffff / da)
+				}
+				v := (sl*srcA + dl*dstA*(1-srcA)) / outA
+				return uint16(float64(fromLinear(v)) * outA)
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: blend(sr, dr),
+				G: blend(sg, dg),
+				B: blend(sb, db),
+				A: uint16(outA * 0xffff),
+			})
+		}
+	}
+}
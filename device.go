@@ -23,160 +23,849 @@
 package streamdeck
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"strings"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/disintegration/gift"
 
 	"github.com/matthewpi/streamdeck/internal/hid"
 )
 
 const (
 	// BrightnessMin is the lowest brightness that can be set on a StreamDeck.
+	//
+	// Brightness is a percentage in the range [BrightnessMin, BrightnessFull],
+	// Stream Deck devices do not support a finer range of brightness.
 	BrightnessMin uint8 = 0
 	// BrightnessFull is the highest brightness that can be set on a StreamDeck.
 	BrightnessFull uint8 = 100
 )
 
+var (
+	// ErrTimeout is matched by errors returned when a transfer to or from a
+	// Device times out.
+	ErrTimeout = hid.ErrTimeout
+	// ErrDisconnected is matched by errors returned when a Device is no longer
+	// connected.
+	ErrDisconnected = hid.ErrDisconnected
+	// ErrBusy is matched by errors returned when a Device is in use.
+	ErrBusy = hid.ErrBusy
+)
+
+// ErrDeviceNotFound is returned when no Device matching the given criteria
+// could be found.
+var ErrDeviceNotFound = errors.New("streamdeck: device not found")
+
+// ErrDeviceBusy is returned when a matching Device was found but it is already
+// in use by another process.
+var ErrDeviceBusy = errors.New("streamdeck: device is in use by another process")
+
+// ErrNoDisplay is returned when trying to display or encode an image for a
+// Device that doesn't have a display, like the Stream Deck Pedal.
+var ErrNoDisplay = errors.New("streamdeck: device does not have a display")
+
+// ErrImageTooLarge is returned when an image is too large to be sent to a
+// Device.
+var ErrImageTooLarge = errors.New("streamdeck: image is too large")
+
+// ErrInvalidImage is returned when an encoded image can't be displayed by a
+// Device.
+var ErrInvalidImage = errors.New("streamdeck: invalid image")
+
+// ErrInvalidButton is returned when a button index is out of range for a
+// Device.
+var ErrInvalidButton = errors.New("streamdeck: invalid key index")
+
+// ButtonError is returned when setting the image displayed by a button fails.
+type ButtonError struct {
+	// Index of the button that failed.
+	Index int
+	// Err is the underlying error.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ButtonError) Error() string {
+	return fmt.Sprintf("streamdeck: failed to set button %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ButtonError) Unwrap() error {
+	return e.Err
+}
+
+// USBInfo contains information about the USB connection to a Device.
+type USBInfo struct {
+	VendorID  uint16
+	ProductID uint16
+	Revision  uint16
+
+	Interface uint8
+	Bus       int
+	Device    int
+
+	// EndpointIn is the address of the input endpoint.
+	EndpointIn uint8
+	// EndpointOut is the address of the output endpoint, zero if the Device
+	// doesn't have an output endpoint.
+	EndpointOut uint8
+
+	// InputPacketSize is the maximum packet size of the input endpoint.
+	InputPacketSize int
+	// OutputPacketSize is the maximum packet size of the output endpoint.
+	OutputPacketSize int
+}
+
 // Device represents a Stream Deck Device.
 type Device struct {
 	DeviceType
 
-	fd         *hid.USB
+	fd Transport
+
+	// writeMx is used to serialize image writes, an image is sent to the
+	// Device in multiple chunks which must not be interleaved with the chunks
+	// of another image. It also protects the blankImage and displayed fields.
+	writeMx    sync.Mutex
 	blankImage []byte
+	// displayed is the last image written to each button, used by
+	// Device#Snapshot.
+	displayed [][]byte
+
+	// closed is true once the Device has been closed.
+	closed atomic.Bool
+	// resetOnClose determines if the Device is reset when it is closed.
+	resetOnClose bool
+	// metrics receives events used to instrument the Device.
+	metrics Metrics
+
+	// rawInputHandlerMx is a mutex used to protect the rawInputHandler field.
+	rawInputHandlerMx sync.Mutex
+	// rawInputHandler is called with every input report read from the Device.
+	rawInputHandler func([]byte)
+
+	// defaultGIFTOnce is used to build defaultGIFT the first time it is used.
+	defaultGIFTOnce sync.Once
+	// defaultGIFT is the GIFT instance used to transform images when no
+	// ImageOptions affecting the transformation are set.
+	defaultGIFT *gift.GIFT
+}
+
+// blankImageKey is used to cache blank images.
+type blankImageKey struct {
+	format ImageFormat
+	size   image.Point
+}
+
+var (
+	// blankImagesMx is a mutex used to protect the blankImages field.
+	blankImagesMx sync.Mutex
+	// blankImages caches the encoded blank image for each format and size.
+	blankImages = make(map[blankImageKey][]byte)
+)
+
+// blankImage returns an encoded blank image using the given format and size.
+func blankImage(format ImageFormat, size image.Point) ([]byte, error) {
+	blankImagesMx.Lock()
+	defer blankImagesMx.Unlock()
+
+	key := blankImageKey{format: format, size: size}
+	if v, ok := blankImages[key]; ok {
+		return v, nil
+	}
+	v, err := format.Blank(size.X, size.Y)
+	if err != nil {
+		return nil, err
+	}
+	blankImages[key] = v
+	return v, nil
 }
 
-// Open attempts to open a connection to a Stream Deck Device.
-func Open(ctx context.Context) (*Device, error) {
-	return OpenPath(ctx, hid.USBDevBus)
+// Open attempts to open a connection to a Stream Deck Device, the path searched
+// for Devices may be changed using WithPath.
+//
+// ErrDeviceNotFound is returned if no Stream Deck could be found, or
+// ErrDeviceBusy if a Stream Deck was found but it is already in use by another
+// process.
+func Open(ctx context.Context, opts ...Option) (*Device, error) {
+	return OpenPath(ctx, newOptions(opts).path, opts...)
 }
 
 // OpenPath attempts to open a connection to a Stream Deck Device at the given
-// path.
-func OpenPath(ctx context.Context, path string) (*Device, error) {
-	d, err := open(ctx, path)
+// path, the path overrides any path set using WithPath.
+//
+// The path may be a directory containing USB devices, or a specific device
+// node in which case no other devices are enumerated. ErrDeviceNotFound is
+// returned if no Stream Deck could be found at the path.
+func OpenPath(ctx context.Context, path string, opts ...Option) (*Device, error) {
+	o := newOptions(opts)
+	d, err := open(ctx, path, o, nil)
 	if err != nil {
 		return nil, err
 	}
 	if d == nil {
-		return nil, nil
+		return nil, ErrDeviceNotFound
 	}
-	if err := d.Reset(ctx); err != nil {
+	if o.resetOnOpen {
+		if err := d.Reset(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// OpenSerial attempts to open a connection to the Stream Deck Device with the
+// given serial number, ErrDeviceNotFound will be returned if no Device has a
+// matching serial number.
+//
+// This is useful to consistently connect to the same Device when multiple
+// Devices are connected.
+func OpenSerial(ctx context.Context, serial string, opts ...Option) (*Device, error) {
+	o := newOptions(opts)
+	d, err := open(ctx, o.path, o, func(d *Device) (bool, error) {
+		v, err := d.SerialNumber(ctx)
+		if err != nil {
+			return false, err
+		}
+		return v == serial, nil
+	})
+	if err != nil {
 		return nil, err
 	}
+	if d == nil {
+		return nil, ErrDeviceNotFound
+	}
+	if o.resetOnOpen {
+		if err := d.Reset(ctx); err != nil {
+			return nil, err
+		}
+	}
 	return d, nil
 }
 
 // open attempts to open a connection to a Stream Deck Device.
-func open(ctx context.Context, path string) (*Device, error) {
+//
+// If match is not nil, it will be called for every Device that is opened and
+// the first Device it returns true for will be returned, any other Devices
+// will be closed.
+//
+// ErrDeviceBusy is returned if no Device could be opened but at least one
+// matching Device is in use by another process.
+func open(ctx context.Context, path string, o options, match func(*Device) (bool, error)) (*Device, error) {
 	// Get a list of all USB HID devices.
-	devices, err := hid.Devices(path)
+	devices, err := hid.Devices(path, o.logSkipped)
 	if err != nil {
 		return nil, err
 	}
 
 	// Iterate over all the devices we found.
+	var busy bool
+	knownDeviceTypes := DeviceTypes()
 	for _, d := range devices {
 		// Iterate over all the device types we have and see if we can find a
 		// match with a supported device.
-		for _, dt := range deviceTypes {
+		for _, dt := range knownDeviceTypes {
 			// Check if the VendorID and ProductID match.
 			if d.Info().VendorID != elgatoVendorID || d.Info().ProductID != dt.ProductID {
 				continue
 			}
 
-			// Get a blank image to use when a button has no image set.
-			blankImage, err := dt.ImageFormat.Blank(dt.ImageSize, dt.ImageSize)
-			if err != nil {
+			// Open a connection to the HID device, if the device was claimed by
+			// another process keep looking as another device may be available.
+			if err := d.Open(ctx); err != nil {
+				if errors.Is(err, ErrBusy) {
+					busy = true
+					break
+				}
 				return nil, err
 			}
+			d.SetMaxAttempts(o.maxWriteAttempts)
 
-			// Open a connection to the HID device.
-			if err := d.Open(ctx); err != nil {
+			device, err := newDevice(dt, usbTransport{d}, o)
+			if err != nil {
+				_ = d.Close(ctx)
 				return nil, err
 			}
+			if match == nil {
+				return device, nil
+			}
 
-			return &Device{
-				DeviceType: dt,
-
-				fd:         d,
-				blankImage: blankImage,
-			}, nil
+			ok, err := match(device)
+			if err != nil {
+				_ = d.Close(ctx)
+				return nil, err
+			}
+			if !ok {
+				if err := d.Close(ctx); err != nil {
+					return nil, err
+				}
+				break
+			}
+			return device, nil
 		}
 	}
 
+	if busy {
+		return nil, ErrDeviceBusy
+	}
 	return nil, nil
 }
 
+// EncodeImage encodes an image to be used with the Device, it is the same as
+// DeviceType#EncodeImage except the GIFT instance used to transform the image
+// is re-used between calls.
+func (d *Device) EncodeImage(img image.Image) ([]byte, error) {
+	return d.EncodeImageWithOptions(img, ImageOptions{})
+}
+
+// EncodeImageWithOptions encodes an image to be used with the Device, it is the
+// same as DeviceType#EncodeImageWithOptions except the GIFT instance used to
+// transform the image is re-used between calls when the ImageOptions don't
+// change how the image is transformed.
+func (d *Device) EncodeImageWithOptions(img image.Image, opts ImageOptions) ([]byte, error) {
+	if !d.HasDisplay() {
+		return nil, fmt.Errorf("%w: %s", ErrNoDisplay, d.Name)
+	}
+	if img == nil {
+		return nil, nil
+	}
+	if !opts.transforms() {
+		d.defaultGIFTOnce.Do(func() {
+			d.defaultGIFT = d.GIFT()
+		})
+		return d.encodeImage(d.defaultGIFT, img, opts)
+	}
+	return d.DeviceType.EncodeImageWithOptions(img, opts)
+}
+
+// SerialNumber reads the serial number of the Device.
+func (d *Device) SerialNumber(ctx context.Context) (string, error) {
+	if d.SerialNumberFunc == nil {
+		return "", fmt.Errorf("streamdeck: %s does not support reading its serial number", d.Name)
+	}
+	return d.SerialNumberFunc(ctx, d.fd.GetFeatureReport)
+}
+
+// ReportDescriptor reads the raw HID report descriptor of the Device.
+//
+// This is intended for debugging, like when working out the layout of the
+// reports sent by a new Device.
+func (d *Device) ReportDescriptor(ctx context.Context) ([]byte, error) {
+	return d.fd.ReportDescriptor(ctx)
+}
+
+// USBInfo returns information about the USB connection to the Device.
+func (d *Device) USBInfo() USBInfo {
+	return d.fd.USBInfo()
+}
+
 // Close resets the Device and closes the USB HID connection to the Stream Deck.
+// If the Device was opened using WithoutResetOnClose, the Device is closed
+// without being reset.
+//
+// The USB HID connection is closed even if resetting the Device fails, any
+// errors are joined together. Calling Close on a Device that is already closed
+// does nothing.
 func (d *Device) Close(ctx context.Context) error {
-	if err := d.Reset(ctx); err != nil {
-		return err
+	return d.close(ctx, d.resetOnClose)
+}
+
+// close closes the USB HID connection to the Stream Deck, resetting the Device
+// and restoring its brightness first if reset is true.
+//
+// The connection is always closed, even if resetting the Device fails.
+func (d *Device) close(ctx context.Context, reset bool) error {
+	if !d.closed.CompareAndSwap(false, true) {
+		return nil
 	}
-	if err := d.SetBrightness(ctx, BrightnessFull); err != nil {
-		return err
+	var resetErr, brightnessErr error
+	if reset {
+		resetErr = d.Reset(ctx)
+		brightnessErr = d.SetBrightness(ctx, BrightnessFull)
 	}
-	return d.fd.Close(ctx)
+	return errors.Join(resetErr, brightnessErr, d.fd.Close(ctx))
 }
 
 // Clear clears all buttons on the Device.
 func (d *Device) Clear(ctx context.Context) error {
-	for i := 0; i < d.ButtonCount(); i++ {
-		if err := d.SetButton(ctx, i, nil); err != nil {
-			return err
-		}
+	if !d.HasDisplay() {
+		return nil
 	}
-	return nil
+	return d.SetButtonsSlice(ctx, make([][]byte, d.ButtonCount()))
+}
+
+// Fill sets every button on the Device to a solid color, the image is only
+// encoded once and written using Device#SetAllButtons.
+func (d *Device) Fill(ctx context.Context, c color.Color) error {
+	if !d.HasDisplay() {
+		return nil
+	}
+
+	size := d.ImageDimensions()
+	img := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	v, err := d.EncodeImage(img)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.SetAllButtons(ctx, v)
+	return err
+}
+
+// ClearButton clears a specific button on the Device, displaying a blank
+// image. This is the same as calling Device#SetButton with a nil image.
+func (d *Device) ClearButton(ctx context.Context, btnIndex int) error {
+	return d.SetButton(ctx, btnIndex, nil)
 }
 
 // Reset resets the Device, restoring its initial state displaying the Elgato
 // logo.
 func (d *Device) Reset(ctx context.Context) error {
-	_, err := d.fd.SendFeatureReport(ctx, d.ResetPacketFunc())
-	return err
+	if _, err := d.fd.SendFeatureReport(ctx, d.ResetPacketFunc()); err != nil {
+		return err
+	}
+
+	d.writeMx.Lock()
+	d.displayed = nil
+	d.writeMx.Unlock()
+	return nil
 }
 
-// SetBrightness sets the brightness of all buttons on the Device.
-func (d *Device) SetBrightness(ctx context.Context, brightness byte) error {
-	_, err := d.fd.SendFeatureReport(ctx, d.BrightnessPacketFunc(brightness))
-	return err
+// SetBrightness sets the brightness of all buttons on the Device, brightness
+// is a percentage in the range [BrightnessMin, BrightnessFull] and will be
+// clamped to that range.
+//
+// Stream Deck devices do not provide a way to read the current brightness, so
+// the brightness can only be written. Resetting the Device does not change its
+// brightness.
+//
+// As the write can't be verified, it is retried if it fails with a transient
+// error, see WithMaxWriteAttempts.
+func (d *Device) SetBrightness(ctx context.Context, brightness uint8) error {
+	brightness = clampBrightness(brightness)
+	if _, err := d.fd.SendFeatureReport(ctx, d.BrightnessPacketFunc(brightness)); err != nil {
+		d.metrics.WriteError(err)
+		return err
+	}
+	d.metrics.BrightnessChanged(brightness)
+	return nil
 }
 
-// SetButton sets the image displayed by a specific button on the Device.
+// SetButton sets the image displayed by a specific button on the Device, if
+// the image is nil the button will be cleared.
+//
+// This method is safe to call concurrently, images are written to the Device
+// one at a time.
 func (d *Device) SetButton(ctx context.Context, btnIndex int, rawImage []byte) error {
+	_, err := d.SetButtonTransfer(ctx, btnIndex, rawImage)
+	return err
+}
+
+// SetButtonRaw sets the image displayed by a specific button on the Device
+// using a payload that was already transformed and encoded for the Device, for
+// example by DeviceType#EncodeImage ahead of time.
+//
+// Unlike Device#SetButton, the payload is validated using
+// DeviceType#ValidateImage before it is written so a payload encoded for a
+// different Device can't corrupt the display. ErrInvalidImage is returned if
+// the payload isn't valid.
+func (d *Device) SetButtonRaw(ctx context.Context, btnIndex int, payload []byte) error {
+	if d.HasDisplay() && payload != nil {
+		if err := d.ValidateImage(payload); err != nil {
+			return err
+		}
+	}
+	return d.SetButton(ctx, btnIndex, payload)
+}
+
+// Transfer contains statistics about the data written to a Device.
+type Transfer struct {
+	// Bytes is the total number of bytes written, including packet headers
+	// and padding.
+	Bytes int
+	// Packets is the number of packets written.
+	Packets int
+}
+
+// writer wraps a write function to record the data written in the Transfer.
+func (t *Transfer) writer(write func(context.Context, []byte) (int, error)) func(context.Context, []byte) (int, error) {
+	return func(ctx context.Context, v []byte) (int, error) {
+		n, err := write(ctx, v)
+		if n > 0 {
+			t.Bytes += n
+			t.Packets++
+		}
+		return n, err
+	}
+}
+
+// SetButtonTransfer is like Device#SetButton but also returns the amount of
+// data written to the Device, this is useful to measure USB utilization.
+//
+// If an error occurs, the returned Transfer contains the data written before
+// the error occurred.
+func (d *Device) SetButtonTransfer(ctx context.Context, btnIndex int, rawImage []byte) (Transfer, error) {
+	if err := d.checkRawImage(rawImage); err != nil {
+		return Transfer{}, err
+	}
+	if btnIndex < 0 || btnIndex >= d.ButtonCount() {
+		return Transfer{}, fmt.Errorf("%w: %d", ErrInvalidButton, btnIndex)
+	}
+
+	d.writeMx.Lock()
+	defer d.writeMx.Unlock()
+	if rawImage == nil {
+		rawImage = d.blankImage
+	}
+
+	var t Transfer
+	err := d.writeButton(ctx, &t, btnIndex, rawImage)
+	return t, err
+}
+
+// SetAllButtons sets every button on the Device to the same image, if the
+// image is nil every button will be cleared.
+//
+// The image is only validated once and the same buffer is written for every
+// button while holding the write lock, so no other writes are interleaved with
+// the update.
+//
+// None of the Stream Deck models support broadcasting an image to multiple
+// buttons, so one transfer is still required per button. The transfers share
+// a single USB endpoint and are written sequentially, parallelizing them would
+// interleave the packets of different buttons. The cost of a full update is
+// the cost of a single image multiplied by Device#ButtonCount, where a single
+// image is split into packets of:
+//
+//   - 8191 bytes with a 16 byte header on the original Stream Deck, a 72x72
+//     BMP image takes 2 packets.
+//   - 1024 bytes with a 16 byte header on the Stream Deck Mini, an 80x80 BMP
+//     image takes 20 packets.
+//   - 1024 bytes with an 8 byte header on all other models, a JPEG image
+//     usually takes between 3 and 10 packets depending on its contents.
+//
+// The returned Transfer contains the total amount of data written, use it to
+// measure the cost of a full update on a specific Device.
+func (d *Device) SetAllButtons(ctx context.Context, rawImage []byte) (Transfer, error) {
+	if err := d.checkRawImage(rawImage); err != nil {
+		return Transfer{}, err
+	}
+
+	d.writeMx.Lock()
+	defer d.writeMx.Unlock()
 	if rawImage == nil {
 		rawImage = d.blankImage
 	}
 
-	if min(max(btnIndex, 0), d.ButtonCount()) != btnIndex {
-		return fmt.Errorf("streamdeck: invalid key index: %d", btnIndex)
+	var t Transfer
+	for i := 0; i < d.ButtonCount(); i++ {
+		if err := ctx.Err(); err != nil {
+			return t, err
+		}
+		if err := d.writeButton(ctx, &t, i, rawImage); err != nil {
+			return t, &ButtonError{Index: i, Err: err}
+		}
+	}
+	return t, nil
+}
+
+// checkRawImage checks if an encoded image can be sent to the Device.
+func (d *Device) checkRawImage(rawImage []byte) error {
+	if !d.HasDisplay() {
+		return fmt.Errorf("%w: %s", ErrNoDisplay, d.Name)
+	}
+
+	// PNG images are only used as an intermediate format and are not
+	// supported by any Device.
+	if bytes.HasPrefix(rawImage, pngSignature) {
+		return fmt.Errorf("streamdeck: cannot send %s image to device", PNG)
+	}
+	if len(rawImage) > d.MaxImageBytes() {
+		return fmt.Errorf("%w: %d bytes exceeds the maximum of %d bytes", ErrImageTooLarge, len(rawImage), d.MaxImageBytes())
+	}
+	return nil
+}
+
+// writeButton writes an encoded image to a button, adding the data written to
+// t. The caller must hold writeMx.
+func (d *Device) writeButton(ctx context.Context, t *Transfer, btnIndex int, rawImage []byte) error {
+	var bt Transfer
+	err := d.DeviceType.ImageTextureFunc(ctx, bt.writer(d.fd.Write), byte(btnIndex), rawImage)
+	t.Bytes += bt.Bytes
+	t.Packets += bt.Packets
+	if err != nil {
+		d.metrics.WriteError(err)
+		return err
 	}
+	d.metrics.ImageWritten(bt)
 
-	return d.DeviceType.ImageTextureFunc(ctx, d.fd.Write, byte(btnIndex), rawImage)
+	if d.displayed == nil {
+		d.displayed = make([][]byte, d.ButtonCount())
+	}
+	d.displayed[btnIndex] = rawImage
+	return nil
 }
 
+// SetTouchStrip sets the image displayed on the segment of the touchscreen
+// above a dial, if the image is nil the segment will be cleared.
+//
+// The image must be encoded using DeviceType#EncodeTouchStripImage.
+func (d *Device) SetTouchStrip(ctx context.Context, segment int, rawImage []byte) error {
+	if !d.HasTouchscreen() || d.TouchscreenTextureFunc == nil {
+		return fmt.Errorf("streamdeck: %s does not have a touchscreen", d.Name)
+	}
+
+	rect := d.TouchStripSegment(segment)
+	if rect.Empty() {
+		return fmt.Errorf("streamdeck: invalid touch strip segment: %d", segment)
+	}
+	if bytes.HasPrefix(rawImage, pngSignature) {
+		return fmt.Errorf("streamdeck: cannot send %s image to device", PNG)
+	}
+	if maxBytes := rect.Dx()*rect.Dy()*4 + 1024; len(rawImage) > maxBytes {
+		return fmt.Errorf("%w: %d bytes exceeds the maximum of %d bytes", ErrImageTooLarge, len(rawImage), maxBytes)
+	}
+
+	if rawImage == nil {
+		v, err := blankImage(d.ImageFormat, rect.Size())
+		if err != nil {
+			return err
+		}
+		rawImage = v
+	}
+
+	d.writeMx.Lock()
+	defer d.writeMx.Unlock()
+
+	var t Transfer
+	if err := d.TouchscreenTextureFunc(ctx, t.writer(d.fd.Write), rect, rawImage); err != nil {
+		d.metrics.WriteError(err)
+		return err
+	}
+	d.metrics.ImageWritten(t)
+	return nil
+}
+
+// SetBlankImage sets the image displayed by buttons that have been cleared or
+// have no image set, by default a black image is used.
+//
+// The image will be processed for the Device, a nil image will restore the
+// default black image.
+func (d *Device) SetBlankImage(img image.Image) error {
+	var (
+		v   []byte
+		err error
+	)
+	if img == nil {
+		v, err = blankImage(d.ImageFormat, d.ImageDimensions())
+	} else {
+		v, err = d.EncodeImage(img)
+	}
+	if err != nil {
+		return err
+	}
+
+	d.writeMx.Lock()
+	d.blankImage = v
+	d.writeMx.Unlock()
+	return nil
+}
+
+// SetButtons sets the images displayed by multiple buttons on the Device, the
+// map is keyed by the index of each button.
+//
+// All indexes are validated before any images are written. If writing any of
+// the images fails, the remaining images will still be written and a
+// ButtonError will be returned for every button that failed.
+//
+// Images are written one at a time as the Device does not support writing
+// multiple images concurrently.
+func (d *Device) SetButtons(ctx context.Context, images map[int][]byte) error {
+	for i := range images {
+		if i < 0 || i >= d.ButtonCount() {
+			return fmt.Errorf("%w: %d", ErrInvalidButton, i)
+		}
+	}
+
+	var errs []error
+	for i, rawImage := range images {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := d.SetButton(ctx, i, rawImage); err != nil {
+			errs = append(errs, &ButtonError{Index: i, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SetButtonsSlice is like Device#SetButtons except that it takes a slice of
+// images, where the index of each image is the index of its button.
+func (d *Device) SetButtonsSlice(ctx context.Context, images [][]byte) error {
+	if len(images) > d.ButtonCount() {
+		return fmt.Errorf("streamdeck: too many images: %d", len(images))
+	}
+
+	var errs []error
+	for i, rawImage := range images {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := d.SetButton(ctx, i, rawImage); err != nil {
+			errs = append(errs, &ButtonError{Index: i, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// buttonEvent represents a button being pressed or released.
+type buttonEvent struct {
+	// index of the button.
+	index int
+	// pressed is true if the button was pressed, false if it was released.
+	pressed bool
+	// at is when the event was received.
+	at time.Time
+	// chord contains every button pressed in the same report, only set if
+	// more than one button was pressed.
+	chord []int
+}
+
+// DialEventKind represents the kind of a DialEvent.
+type DialEventKind uint8
+
+const (
+	// DialRotate is sent when a dial is rotated.
+	DialRotate DialEventKind = iota
+	// DialPress is sent when a dial is pressed.
+	DialPress
+	// DialRelease is sent when a dial is released.
+	DialRelease
+)
+
+// DialEvent represents a dial on a Device being rotated, pressed, or released.
+type DialEvent struct {
+	// Index of the dial, dials are indexed from left to right starting at
+	// zero.
+	Index int
+	// Kind of the event.
+	Kind DialEventKind
+	// Delta is the number of steps the dial was rotated by, positive values
+	// are clockwise. Delta is only set for DialRotate events.
+	Delta int
+}
+
+const (
+	// inputReportKeys is the type of an input report containing key states.
+	inputReportKeys = 0x00
+	// inputReportDials is the type of an input report containing dial events.
+	inputReportDials = 0x03
+)
+
 // buttonPressListener listens for button presses over the USB HID bus.
-func (d *Device) buttonPressListener(ctx context.Context, ch chan int) error {
+//
+// If initial is true, the current state of every button will be requested
+// from the Device and a press event will be sent for any buttons that are
+// already held down.
+//
+// If dialCh is not nil, events for any dials on the Device will be sent to it.
+func (d *Device) buttonPressListener(ctx context.Context, ch chan buttonEvent, dialCh chan DialEvent, initial bool) error {
 	numberOfButtons := d.ButtonCount()
 	readOffset := d.ButtonOffset
 
-	// TODO: figure out what the proper size to use here is.
-	// Trying to set it to readOffset+numberOfButtons caused the ioctl syscall
-	// to get very ANGERY at us.
-	// I've tried 288 (36 * 8), 384, and only 512 seems to work.
-	states := make([]byte, 512)
+	// dialPressed tracks the last known state of each dial.
+	dialPressed := make([]bool, d.Dials)
+
+	// dials sends events for a dial input report.
+	dials := func(report []byte) error {
+		if dialCh == nil {
+			return nil
+		}
+		for _, event := range parseDialReport(report, dialPressed) {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case dialCh <- event:
+			}
+		}
+		return nil
+	}
+
+	// pressed tracks the last known state of each button, used to only send
+	// events when the state of a button changes.
+	pressed := make([]bool, numberOfButtons)
+
+	// update sends events for any buttons whose state has changed.
+	//
+	// Every event sent for the same report shares the same time, and presses
+	// of multiple buttons in the same report are sent with the full chord so
+	// they can be handled together.
+	update := func(states []byte) error {
+		at := time.Now()
+		var events []buttonEvent
+		var chord []int
+		for i := 0; i < numberOfButtons; i++ {
+			isPressed := states[readOffset+i] == 1
+			if pressed[i] == isPressed {
+				continue
+			}
+			pressed[i] = isPressed
+			if isPressed {
+				d.metrics.ButtonPressed(i)
+				chord = append(chord, i)
+			}
+			events = append(events, buttonEvent{index: i, pressed: isPressed, at: at})
+		}
+		if len(chord) < 2 {
+			chord = nil
+		}
+
+		for _, event := range events {
+			if event.pressed {
+				event.chord = chord
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case ch <- event:
+			}
+		}
+		return nil
+	}
+
+	states := make([]byte, d.readSize())
+	if initial {
+		// Devices that don't support requesting an input report will only
+		// send events once a button changes state.
+		states[0] = 0x01
+		if _, err := d.fd.GetInputReport(ctx, states); err == nil {
+			if err := update(states); err != nil {
+				return err
+			}
+		}
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
-			// Zero the entire states array.
-			for i := range states {
+			// Zero the part of the states array containing button states.
+			for i := readOffset; i < readOffset+numberOfButtons; i++ {
 				states[i] = 0x0
 			}
 
 			n, err := d.fd.Read(ctx, states, 0)
 			if err != nil {
-				if strings.Contains(err.Error(), "timed out") {
+				if errors.Is(err, ErrTimeout) {
 					continue
 				}
 				return err
@@ -185,27 +874,122 @@ func (d *Device) buttonPressListener(ctx context.Context, ch chan int) error {
 				return nil
 			}
 
-			for i := 0; i < numberOfButtons; i++ {
-				if states[readOffset+i] != 1 {
-					continue
+			d.rawInputHandlerMx.Lock()
+			rawInputHandler := d.rawInputHandler
+			d.rawInputHandlerMx.Unlock()
+			if rawInputHandler != nil {
+				report := make([]byte, n)
+				copy(report, states[:n])
+				rawInputHandler(report)
+			}
+
+			// Devices using a four byte header send other kinds of input
+			// reports, like dial events, that must not be treated as button
+			// states.
+			if readOffset == 4 && states[1] != inputReportKeys {
+				if states[1] == inputReportDials {
+					if err := dials(states[:n]); err != nil {
+						return err
+					}
 				}
-				ch <- i
+				continue
+			}
+
+			if err := update(states); err != nil {
+				return err
 			}
 		}
 	}
 }
 
-// min is the same as math#Min except that it uses int as the type.
-func min(x, y int) int {
-	if x < y {
-		return x
+const (
+	// dialReportPush is the kind of a dial input report containing the
+	// pressed state of each dial.
+	dialReportPush = 0x00
+	// dialReportTurn is the kind of a dial input report containing the number
+	// of steps each dial was rotated by, as a signed byte.
+	dialReportTurn = 0x01
+)
+
+// parseDialReport returns the events in a dial input report, the fifth byte of
+// the report is its kind and is followed by a value for each dial.
+//
+// pressed contains the last known state of each dial, it is updated with the
+// states in the report so only changes are returned as events.
+func parseDialReport(report []byte, pressed []bool) []DialEvent {
+	if len(report) < 5 {
+		return nil
 	}
-	return y
+	var events []DialEvent
+	for i := 0; i < len(pressed) && 5+i < len(report); i++ {
+		v := report[5+i]
+		switch report[4] {
+		case dialReportTurn:
+			if delta := int(int8(v)); delta != 0 {
+				events = append(events, DialEvent{Index: i, Kind: DialRotate, Delta: delta})
+			}
+		case dialReportPush:
+			isPressed := v == 1
+			if pressed[i] == isPressed {
+				continue
+			}
+			pressed[i] = isPressed
+			kind := DialRelease
+			if isPressed {
+				kind = DialPress
+			}
+			events = append(events, DialEvent{Index: i, Kind: kind})
+		}
+	}
+	return events
 }
 
-// max is the same as math#Max except that it uses int as the type.
-func max(x, y int) int {
-	if x > y {
+// SetRawInputHandler sets a handler called with every input report read from
+// the Device, before it is parsed into button or dial events.
+//
+// This is an escape hatch for decoding input from Devices this library doesn't
+// fully support yet. The handler is called synchronously by the goroutine that
+// reads from the Device and should not block, the report may be retained.
+//
+// Reports are only read while the Device is being used by a StreamDeck.
+func (d *Device) SetRawInputHandler(fn func(report []byte)) {
+	d.rawInputHandlerMx.Lock()
+	defer d.rawInputHandlerMx.Unlock()
+
+	d.rawInputHandler = fn
+}
+
+// readSize returns the size of the buffer used to read input reports.
+//
+// Reads must use the maximum packet size of the input endpoint, otherwise the
+// transfer will fail if the Device sends a larger packet than the buffer can
+// hold.
+func (d *Device) readSize() int {
+	size := d.fd.USBInfo().InputPacketSize
+	if size == 0 {
+		// Fallback to a size large enough for any known Device if the input
+		// endpoint didn't report a packet size.
+		size = 512
+	}
+	// Always ensure the buffer is large enough to contain every button state.
+	if required := d.ButtonOffset + d.ButtonCount(); size < required {
+		size = required
+	}
+	return size
+}
+
+// clampBrightness clamps brightness to the range [BrightnessMin, BrightnessFull].
+// BrightnessMin is 0, so only the upper bound needs to be checked.
+func clampBrightness(brightness uint8) uint8 {
+	if brightness > BrightnessFull {
+		return BrightnessFull
+	}
+	return brightness
+}
+
+// min is the same as math#Min except that it uses int as the type.
+func min(x, y int) int {
+	if x < y {
 		return x
 	}
 	return y
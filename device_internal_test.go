@@ -0,0 +1,80 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package streamdeck
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseDialReport feeds dial input reports recorded from a Stream Deck
+// Plus through the parser.
+func TestParseDialReport(t *testing.T) {
+	// Dial input reports start with the report id, the report type, and the
+	// length of the report, followed by the kind of report and a value for
+	// each dial.
+	tests := []struct {
+		name   string
+		report []byte
+		want   []DialEvent
+	}{
+		{
+			name:   "turn",
+			report: []byte{0x01, 0x03, 0x05, 0x00, 0x01, 0x00, 0xff, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			want: []DialEvent{
+				{Index: 1, Kind: DialRotate, Delta: -1},
+				{Index: 2, Kind: DialRotate, Delta: 2},
+			},
+		},
+		{
+			name:   "push",
+			report: []byte{0x01, 0x03, 0x05, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			want:   []DialEvent{{Index: 2, Kind: DialPress}},
+		},
+		{
+			name:   "push held",
+			report: []byte{0x01, 0x03, 0x05, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			want:   nil,
+		},
+		{
+			name:   "release",
+			report: []byte{0x01, 0x03, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			want:   []DialEvent{{Index: 2, Kind: DialRelease}},
+		},
+		{
+			name:   "short",
+			report: []byte{0x01, 0x03, 0x05, 0x00},
+			want:   nil,
+		},
+	}
+
+	// The state of the dials is shared between reports, like it is when
+	// reading reports from a Device.
+	pressed := make([]bool, 4)
+	for _, tt := range tests {
+		got := parseDialReport(tt.report, pressed)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.want, got)
+		}
+	}
+}
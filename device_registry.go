@@ -22,6 +22,15 @@
 
 package streamdeck
 
+import (
+	"fmt"
+	"image"
+	"sync"
+)
+
+// deviceTypesMx is a mutex used to protect the deviceTypes slice.
+var deviceTypesMx sync.RWMutex
+
 // deviceTypes is a list of known Elgato Stream Deck devices.
 var deviceTypes = []DeviceType{
 	// Stream Deck
@@ -38,6 +47,7 @@ var deviceTypes = []DeviceType{
 		BrightnessPacketFunc: brightnessPacketGen1,
 		ResetPacketFunc:      resetPacketGen1,
 		ImageTextureFunc:     imageTextureGen1,
+		SerialNumberFunc:     serialNumberGen1,
 	},
 	// Stream Deck MK.2
 	{
@@ -53,6 +63,7 @@ var deviceTypes = []DeviceType{
 		BrightnessPacketFunc: brightnessPacketGen2,
 		ResetPacketFunc:      resetPacketGen2,
 		ImageTextureFunc:     imageTextureGen2,
+		SerialNumberFunc:     serialNumberGen2,
 	},
 	// Stream Deck Mini
 	{
@@ -62,12 +73,13 @@ var deviceTypes = []DeviceType{
 		Cols:         3,
 		ImageFormat:  BMP,
 		ImageSize:    80,
-		ImageFlags:   ImageFlagFlipY | ImageFlagRotate90,
+		ImageFlags:   ImageFlagFlipX | ImageFlagRotate90,
 		ButtonOffset: 1,
 
 		BrightnessPacketFunc: brightnessPacketGen1,
 		ResetPacketFunc:      resetPacketGen1,
 		ImageTextureFunc:     imageTextureMini,
+		SerialNumberFunc:     serialNumberGen1,
 	},
 	// Stream Deck Mini v2
 	{
@@ -77,12 +89,13 @@ var deviceTypes = []DeviceType{
 		Cols:         3,
 		ImageFormat:  BMP,
 		ImageSize:    80,
-		ImageFlags:   ImageFlagFlipY | ImageFlagRotate90,
+		ImageFlags:   ImageFlagFlipX | ImageFlagRotate90,
 		ButtonOffset: 1,
 
 		BrightnessPacketFunc: brightnessPacketGen1,
 		ResetPacketFunc:      resetPacketGen1,
 		ImageTextureFunc:     imageTextureMini,
+		SerialNumberFunc:     serialNumberGen1,
 	},
 	// Stream Deck XL
 	{
@@ -98,6 +111,7 @@ var deviceTypes = []DeviceType{
 		BrightnessPacketFunc: brightnessPacketGen2,
 		ResetPacketFunc:      resetPacketGen2,
 		ImageTextureFunc:     imageTextureGen2,
+		SerialNumberFunc:     serialNumberGen2,
 	},
 	// Stream Deck XL v2 (same as the XL but different product id)
 	{
@@ -113,21 +127,119 @@ var deviceTypes = []DeviceType{
 		BrightnessPacketFunc: brightnessPacketGen2,
 		ResetPacketFunc:      resetPacketGen2,
 		ImageTextureFunc:     imageTextureGen2,
+		SerialNumberFunc:     serialNumberGen2,
 	},
 	// Stream Deck Plus
 	// TODO: this Stream Deck needs a more advanced read handler to handle
-	// inputs from the touchscreen and dials.
+	// inputs from the touchscreen.
 	{
 		Name:         "Stream Deck Plus",
 		ProductID:    0x84,
-		Rows:         4,
-		Cols:         2,
+		Rows:         2,
+		Cols:         4,
 		ImageFormat:  JPEG,
 		ImageSize:    120,
+		Dials:        4,
+		Touchscreen:  image.Point{X: 800, Y: 100},
 		ButtonOffset: 4,
 
 		BrightnessPacketFunc: brightnessPacketGen2,
 		ResetPacketFunc:      resetPacketGen2,
 		ImageTextureFunc:     imageTextureGen2,
+		SerialNumberFunc:     serialNumberGen2,
+
+		TouchscreenTextureFunc: touchscreenTexturePlus,
 	},
 }
+
+// init validates the known device types, a malformed entry is a bug in this
+// library and should never be able to ship.
+func init() {
+	for _, dt := range deviceTypes {
+		if err := dt.Validate(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterDeviceType registers a DeviceType, allowing Open to connect to
+// devices that are not known by this library.
+//
+// An error will be returned if the DeviceType is invalid, see
+// DeviceType.Validate, or if a DeviceType with the same ProductID is already
+// registered.
+//
+// This function is safe to call concurrently.
+func RegisterDeviceType(dt DeviceType) error {
+	if err := dt.Validate(); err != nil {
+		return err
+	}
+
+	deviceTypesMx.Lock()
+	defer deviceTypesMx.Unlock()
+
+	for _, v := range deviceTypes {
+		if v.ProductID == dt.ProductID {
+			return fmt.Errorf("streamdeck: device type with product id 0x%04x is already registered", dt.ProductID)
+		}
+	}
+	deviceTypes = append(deviceTypes, dt)
+	return nil
+}
+
+// ReplaceDeviceType registers a DeviceType, replacing any DeviceType already
+// registered with the same ProductID.
+//
+// This is useful to patch a known DeviceType for a Device with quirky
+// firmware, for example:
+//
+//	dt, _ := DeviceTypeByProductID(0x0080)
+//	err := ReplaceDeviceType(dt.WithResetPacket(myResetPacket))
+//
+// An error will be returned if the DeviceType is invalid, see
+// DeviceType.Validate. This function is safe to call concurrently.
+func ReplaceDeviceType(dt DeviceType) error {
+	if err := dt.Validate(); err != nil {
+		return err
+	}
+
+	deviceTypesMx.Lock()
+	defer deviceTypesMx.Unlock()
+
+	for i, v := range deviceTypes {
+		if v.ProductID == dt.ProductID {
+			deviceTypes[i] = dt
+			return nil
+		}
+	}
+	deviceTypes = append(deviceTypes, dt)
+	return nil
+}
+
+// DeviceTypes returns a copy of all registered DeviceTypes.
+//
+// This function is safe to call concurrently.
+func DeviceTypes() []DeviceType {
+	deviceTypesMx.RLock()
+	defer deviceTypesMx.RUnlock()
+
+	res := make([]DeviceType, len(deviceTypes))
+	copy(res, deviceTypes)
+	return res
+}
+
+// DeviceTypeByProductID returns the registered DeviceType with the given
+// ProductID, ok will be false if no DeviceType matches.
+//
+// This function is safe to call concurrently.
+func DeviceTypeByProductID(id uint16) (dt DeviceType, ok bool) {
+	deviceTypesMx.RLock()
+	defer deviceTypesMx.RUnlock()
+
+	for _, v := range deviceTypes {
+		if v.ProductID == id {
+			return v, true
+		}
+	}
+	return DeviceType{}, false
+}
@@ -0,0 +1,274 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package streamdeck_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"math/rand"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/matthewpi/streamdeck"
+	"github.com/matthewpi/streamdeck/streamdecktest"
+)
+
+// Product ids of the Devices used by the tests.
+const (
+	productOriginal = 0x60
+	productXL       = 0x6c
+	productPlus     = 0x84
+)
+
+// testImage returns a unique image for a Device, the image spans multiple
+// packets so its packets may be interleaved with those of another image.
+func testImage(seed int) []byte {
+	b := make([]byte, 3000)
+	for i := range b {
+		b[i] = byte(seed + i)
+	}
+	return b
+}
+
+// TestSetButtonConcurrent writes images to multiple buttons concurrently and
+// checks that the packets of an image are never interleaved with the packets
+// of another image. Run with -race to also check for data races.
+func TestSetButtonConcurrent(t *testing.T) {
+	dt := streamdecktest.DeviceType(t, productXL)
+	d, tr := streamdecktest.NewDevice(t, dt)
+	// Yield between packets to give other writers a chance to interleave.
+	tr.OnWrite = func([]byte) error {
+		runtime.Gosched()
+		return nil
+	}
+
+	const writesPerButton = 10
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < dt.ButtonCount(); i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < writesPerButton; j++ {
+				if err := d.SetButton(ctx, i, testImage(i)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every image must be sent as a contiguous run of packets, starting at
+	// page zero and ending with the last packet.
+	var (
+		button = -1
+		page   int
+	)
+	for n, p := range tr.Writes() {
+		b := int(p[2])
+		pg := int(binary.LittleEndian.Uint16(p[6:8]))
+		if button == -1 {
+			if pg != 0 {
+				t.Fatalf("packet %d: image for button %d starts at page %d", n, b, pg)
+			}
+			button = b
+		} else if b != button || pg != page {
+			t.Fatalf("packet %d: expected page %d of button %d, got page %d of button %d", n, page, button, pg, b)
+		}
+		page = pg + 1
+		if p[3] == 0x01 {
+			button, page = -1, 0
+		}
+	}
+	if button != -1 {
+		t.Fatal("last image was not completed")
+	}
+
+	for i := 0; i < dt.ButtonCount(); i++ {
+		if n := tr.ImageCount(i); n != writesPerButton {
+			t.Errorf("button %d: expected %d images, got %d", i, writesPerButton, n)
+		}
+		if got := tr.Image(i); string(got) != string(testImage(i)) {
+			t.Errorf("button %d: image was corrupted", i)
+		}
+	}
+}
+
+// TestDeviceCloseTwice checks that a Device is only reset and closed once.
+func TestDeviceCloseTwice(t *testing.T) {
+	d, tr := streamdecktest.NewDevice(t, streamdecktest.DeviceType(t, productOriginal))
+
+	ctx := context.Background()
+	if err := d.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	reports := len(tr.FeatureReports())
+	if err := d.Close(ctx); err != nil {
+		t.Fatalf("expected closing a closed device to do nothing, got %v", err)
+	}
+	if n := tr.CloseCount(); n != 1 {
+		t.Errorf("expected the transport to be closed once, got %d", n)
+	}
+	if n := len(tr.FeatureReports()); n != reports {
+		t.Errorf("expected no feature reports after the device was closed, got %d", n-reports)
+	}
+}
+
+// TestDeviceCloseResetFails checks that the transport is closed even if the
+// Device can't be reset.
+func TestDeviceCloseResetFails(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		open func(*testing.T) (func(context.Context) error, *streamdecktest.Transport)
+	}{
+		{
+			name: "Device",
+			open: func(t *testing.T) (func(context.Context) error, *streamdecktest.Transport) {
+				d, tr := streamdecktest.NewDevice(t, streamdecktest.DeviceType(t, productOriginal))
+				return d.Close, tr
+			},
+		},
+		{
+			name: "StreamDeck",
+			open: func(t *testing.T) (func(context.Context) error, *streamdecktest.Transport) {
+				sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productOriginal))
+				return sd.Close, tr
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			closeFn, tr := tc.open(t)
+			errReport := errors.New("feature report failed")
+			tr.OnFeatureReport = func([]byte) error { return errReport }
+
+			ctx := context.Background()
+			if err := closeFn(ctx); !errors.Is(err, errReport) {
+				t.Errorf("expected the reset error to be returned, got %v", err)
+			}
+			if n := tr.CloseCount(); n != 1 {
+				t.Errorf("expected the transport to be closed once, got %d", n)
+			}
+			if err := closeFn(ctx); err != nil {
+				t.Errorf("expected closing again to do nothing, got %v", err)
+			}
+			if n := tr.CloseCount(); n != 1 {
+				t.Errorf("expected the transport to be closed once, got %d", n)
+			}
+		})
+	}
+}
+
+// TestSetButtonIndex checks that only the indexes of buttons on the Device are
+// accepted, and that nothing is written for an invalid index.
+func TestSetButtonIndex(t *testing.T) {
+	for _, dt := range streamdeck.DeviceTypes() {
+		if !dt.HasDisplay() {
+			continue
+		}
+		dt := dt
+		t.Run(fmt.Sprintf("0x%02x", dt.ProductID), func(t *testing.T) {
+			d, tr := streamdecktest.NewDevice(t, dt)
+			ctx := context.Background()
+
+			count := dt.ButtonCount()
+			for _, tc := range []struct {
+				index int
+				valid bool
+			}{
+				{index: 0, valid: true},
+				{index: count - 1, valid: true},
+				{index: count, valid: false},
+				{index: -1, valid: false},
+			} {
+				writes := len(tr.Writes())
+				err := d.SetButton(ctx, tc.index, testImage(tc.index))
+				if !tc.valid {
+					if !errors.Is(err, streamdeck.ErrInvalidButton) {
+						t.Errorf("index %d: expected ErrInvalidButton, got %v", tc.index, err)
+					}
+					if n := len(tr.Writes()); n != writes {
+						t.Errorf("index %d: expected nothing to be written, got %d packets", tc.index, n-writes)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("index %d: %v", tc.index, err)
+				} else if n := tr.ImageCount(tc.index); n != 1 {
+					t.Errorf("index %d: expected 1 image to be written, got %d", tc.index, n)
+				}
+			}
+
+			if err := d.SetButtons(ctx, map[int][]byte{0: testImage(0), count: testImage(count)}); !errors.Is(err, streamdeck.ErrInvalidButton) {
+				t.Errorf("SetButtons: expected ErrInvalidButton, got %v", err)
+			}
+		})
+	}
+}
+
+// TestSetButtonHighEntropy checks that an image of random noise, which can't
+// be compressed, is accepted and sent intact over many packets.
+func TestSetButtonHighEntropy(t *testing.T) {
+	for _, dt := range streamdeck.DeviceTypes() {
+		if !dt.HasDisplay() {
+			continue
+		}
+		dt := dt
+		t.Run(fmt.Sprintf("0x%02x", dt.ProductID), func(t *testing.T) {
+			d, tr := streamdecktest.NewDevice(t, dt)
+
+			r := rand.New(rand.NewSource(int64(dt.ProductID)))
+			img := image.NewRGBA(image.Rectangle{Max: dt.ImageDimensions()})
+			r.Read(img.Pix)
+			rawImage, err := dt.EncodeImageWithOptions(img, streamdeck.ImageOptions{Quality: 100})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rawImage) > dt.MaxImageBytes() {
+				t.Fatalf("expected the image to fit in %d bytes, got %d", dt.MaxImageBytes(), len(rawImage))
+			}
+
+			transfer, err := d.SetButtonTransfer(context.Background(), 0, rawImage)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if transfer.Packets < 2 || transfer.Packets != len(tr.Writes()) {
+				t.Errorf("expected the image to span %d packets, got %d", len(tr.Writes()), transfer.Packets)
+			}
+			// Images sent to some Devices are padded with zeros to the size of
+			// a packet.
+			written := tr.Image(0)
+			if len(written) < len(rawImage) || !bytes.Equal(written[:len(rawImage)], rawImage) ||
+				!bytes.Equal(written[len(rawImage):], make([]byte, len(written)-len(rawImage))) {
+				t.Error("expected the image written to the button to match the encoded image")
+			}
+		})
+	}
+}
@@ -23,8 +23,13 @@
 package streamdeck
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"fmt"
 	"image"
+	"strconv"
+	"strings"
 
 	"github.com/disintegration/gift"
 )
@@ -52,12 +57,32 @@ type DeviceType struct {
 	// ImageFormat used to encode images displayed on the Device.
 	ImageFormat ImageFormat
 
-	// ImageSize to use to transform images for the Device.
+	// ImageSize to use to transform images for the Device, it is used as both
+	// the width and height of the image unless ImageBounds is set.
 	ImageSize int
 
+	// ImageBounds is the width and height to use to transform images for the
+	// Device, it only needs to be set if the Device's buttons are not square.
+	ImageBounds image.Point
+
 	// ImageFlags to apply to images before displaying them on the Device.
 	ImageFlags ImageFlags
 
+	// Resampling is the filter used to resize images for the Device, if nil
+	// gift.LanczosResampling will be used.
+	Resampling gift.Resampling
+
+	// ImageQuality used to encode images for the Device, only used by lossy
+	// image formats. If zero, DefaultImageQuality will be used.
+	ImageQuality int
+
+	// Dials is the number of rotary dials on the Device.
+	Dials int
+
+	// Touchscreen is the size of the touchscreen on the Device, or zero if the
+	// Device doesn't have a touchscreen.
+	Touchscreen image.Point
+
 	// ButtonOffset is the offset to used to detect what physical button on the
 	// device was pressed. This offset value varies by generation, but is
 	// usually either `1` or `4`.
@@ -72,6 +97,13 @@ type DeviceType struct {
 
 	// ImageTextureFunc sets an image on the Device.
 	ImageTextureFunc
+
+	// TouchscreenTextureFunc sets an image on an area of the Device's
+	// touchscreen, nil if the Device doesn't have a touchscreen.
+	TouchscreenTextureFunc
+
+	// SerialNumberFunc reads the serial number of the Device.
+	SerialNumberFunc
 }
 
 // ButtonCount returns the total number of buttons on the Device.
@@ -79,30 +111,435 @@ func (t DeviceType) ButtonCount() int {
 	return t.Rows * t.Cols
 }
 
+// Validate returns an error if the DeviceType is missing information required
+// to use the Device.
+//
+// A DeviceType is considered to have a display if it sets an image size or an
+// ImageTextureFunc, in which case both must be set along with a supported
+// ImageFormat and BrightnessPacketFunc.
+func (t DeviceType) Validate() error {
+	if t.ProductID == 0 {
+		return fmt.Errorf("streamdeck: device type %q: product id must be set", t.Name)
+	}
+	if t.Rows <= 0 || t.Cols <= 0 {
+		return fmt.Errorf("streamdeck: device type %q: rows and cols must be greater than 0", t.Name)
+	}
+	if t.ButtonOffset < 0 {
+		return fmt.Errorf("streamdeck: device type %q: button offset must not be negative", t.Name)
+	}
+	if t.Dials < 0 {
+		return fmt.Errorf("streamdeck: device type %q: dials must not be negative", t.Name)
+	}
+	if t.ResetPacketFunc == nil {
+		return fmt.Errorf("streamdeck: device type %q: ResetPacketFunc must be set", t.Name)
+	}
+
+	size := t.ImageDimensions()
+	if size == (image.Point{}) && t.ImageTextureFunc == nil {
+		// The Device doesn't have a display.
+		return nil
+	}
+	if size.X <= 0 || size.Y <= 0 {
+		return fmt.Errorf("streamdeck: device type %q: image size must be greater than 0", t.Name)
+	}
+	if t.ImageTextureFunc == nil {
+		return fmt.Errorf("streamdeck: device type %q: ImageTextureFunc must be set", t.Name)
+	}
+	if t.BrightnessPacketFunc == nil {
+		return fmt.Errorf("streamdeck: device type %q: BrightnessPacketFunc must be set", t.Name)
+	}
+	if !t.ImageFormat.IsWireFormat() {
+		return fmt.Errorf("streamdeck: device type %q: unsupported image format: %q", t.Name, t.ImageFormat)
+	}
+	return nil
+}
+
+// WithBrightnessPacket returns a copy of the DeviceType using fn to build
+// brightness packets.
+//
+// The With methods allow a known DeviceType to be patched for a Device with
+// quirky firmware, see ReplaceDeviceType.
+func (t DeviceType) WithBrightnessPacket(fn BrightnessPacketFunc) DeviceType {
+	t.BrightnessPacketFunc = fn
+	return t
+}
+
+// WithResetPacket returns a copy of the DeviceType using fn to build reset
+// packets.
+func (t DeviceType) WithResetPacket(fn ResetPacketFunc) DeviceType {
+	t.ResetPacketFunc = fn
+	return t
+}
+
+// WithImageTexture returns a copy of the DeviceType using fn to set the image
+// displayed by a button.
+func (t DeviceType) WithImageTexture(fn ImageTextureFunc) DeviceType {
+	t.ImageTextureFunc = fn
+	return t
+}
+
+// WithTouchscreenTexture returns a copy of the DeviceType using fn to set an
+// image on the touchscreen.
+func (t DeviceType) WithTouchscreenTexture(fn TouchscreenTextureFunc) DeviceType {
+	t.TouchscreenTextureFunc = fn
+	return t
+}
+
+// WithSerialNumber returns a copy of the DeviceType using fn to read the serial
+// number of the Device.
+func (t DeviceType) WithSerialNumber(fn SerialNumberFunc) DeviceType {
+	t.SerialNumberFunc = fn
+	return t
+}
+
+// MaxImageBytes returns the maximum size of an encoded image that may be sent
+// to the Device.
+//
+// An encoded image should never be larger than the raw pixel data of an image
+// plus some room for headers, anything larger is almost certainly not a valid
+// image for the Device and may corrupt its display.
+func (t DeviceType) MaxImageBytes() int {
+	size := t.ImageDimensions()
+	return size.X*size.Y*4 + 1024
+}
+
+// ImageDimensions returns the width and height of images displayed on the
+// Device's buttons, ImageBounds is used if set, otherwise ImageSize is used for
+// both dimensions.
+func (t DeviceType) ImageDimensions() image.Point {
+	if t.ImageBounds.X > 0 && t.ImageBounds.Y > 0 {
+		return t.ImageBounds
+	}
+	return image.Pt(t.ImageSize, t.ImageSize)
+}
+
+// HasDisplay returns true if the buttons on the Device are able to display
+// images.
+func (t DeviceType) HasDisplay() bool {
+	size := t.ImageDimensions()
+	return size.X > 0 && size.Y > 0 && t.ImageTextureFunc != nil
+}
+
+// HasDials returns true if the Device has rotary dials.
+func (t DeviceType) HasDials() bool {
+	return t.Dials > 0
+}
+
+// HasTouchscreen returns true if the Device has a touchscreen.
+func (t DeviceType) HasTouchscreen() bool {
+	return t.Touchscreen.X > 0 && t.Touchscreen.Y > 0
+}
+
+// TouchStripSegment returns the area of the touchscreen above a dial, the
+// touchscreen is split into equally sized segments, one for each dial.
+//
+// An empty rectangle is returned if the Device doesn't have a touchscreen and
+// dials or if the segment is out of range.
+func (t DeviceType) TouchStripSegment(segment int) image.Rectangle {
+	if !t.HasTouchscreen() || !t.HasDials() || segment < 0 || segment >= t.Dials {
+		return image.Rectangle{}
+	}
+	width := t.Touchscreen.X / t.Dials
+	return image.Rect(segment*width, 0, (segment+1)*width, t.Touchscreen.Y)
+}
+
+// EncodeTouchStripImage encodes an image to be displayed on a segment of the
+// Device's touchscreen, see DeviceType#TouchStripSegment. The image will be
+// resized to the size of a segment.
+//
+// A nil image will be encoded as a blank image.
+func (t DeviceType) EncodeTouchStripImage(img image.Image) ([]byte, error) {
+	size := t.TouchStripSegment(0).Size()
+	if size.X == 0 || size.Y == 0 {
+		return nil, fmt.Errorf("streamdeck: %s does not have a touchscreen", t.Name)
+	}
+	if img == nil {
+		return t.ImageFormat.Blank(size.X, size.Y)
+	}
+
+	resampling := t.Resampling
+	if resampling == nil {
+		resampling = gift.LanczosResampling
+	}
+	g := gift.New(gift.Resize(size.X, size.Y, resampling))
+	res := image.NewRGBA(g.Bounds(img.Bounds()))
+	g.Draw(res, img)
+
+	quality := t.ImageQuality
+	if quality == 0 {
+		quality = DefaultImageQuality
+	}
+	return t.ImageFormat.EncodeQuality(res, quality)
+}
+
+// ButtonAt returns the index of the button at the given row and column, ok
+// will be false if the row or column is out of range.
+//
+// Buttons are indexed from left to right, top to bottom starting at zero.
+func (t DeviceType) ButtonAt(row, col int) (index int, ok bool) {
+	if row < 0 || row >= t.Rows || col < 0 || col >= t.Cols {
+		return 0, false
+	}
+	return row*t.Cols + col, true
+}
+
+// RowCol returns the row and column of the button at the given index, it is
+// the inverse of DeviceType#ButtonAt.
+func (t DeviceType) RowCol(index int) (row, col int) {
+	if t.Cols == 0 {
+		return 0, 0
+	}
+	return index / t.Cols, index % t.Cols
+}
+
+// FormatButtonLabel returns a human-readable label for the button at index, in
+// the form "R<row>C<col>" with the row and column starting at zero.
+//
+// Labels are useful to reference buttons in configuration files, see
+// DeviceType#ParseButtonLabel.
+func (t DeviceType) FormatButtonLabel(index int) string {
+	row, col := t.RowCol(index)
+	return fmt.Sprintf("R%dC%d", row, col)
+}
+
+// ParseButtonLabel returns the index of the button referenced by a label,
+// labels are case-insensitive and may be in the form "R<row>C<col>" as
+// returned by DeviceType#FormatButtonLabel, or "B<n>" where n is the button's
+// index starting at one.
+//
+// ErrInvalidButton is returned if the label is malformed or references a
+// button that doesn't exist on the Device.
+func (t DeviceType) ParseButtonLabel(label string) (int, error) {
+	upper := strings.ToUpper(strings.TrimSpace(label))
+	var (
+		index int
+		ok    bool
+	)
+	switch {
+	case strings.HasPrefix(upper, "B"):
+		if n, valid := parseLabelNumber(upper[1:]); valid {
+			index, ok = n-1, n >= 1 && n <= t.ButtonCount()
+		}
+	case strings.HasPrefix(upper, "R"):
+		r, c, _ := strings.Cut(upper[1:], "C")
+		row, rowOk := parseLabelNumber(r)
+		col, colOk := parseLabelNumber(c)
+		if rowOk && colOk {
+			index, ok = t.ButtonAt(row, col)
+		}
+	}
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidButton, label)
+	}
+	return index, nil
+}
+
+// parseLabelNumber parses a non-negative number in a button label, signs and
+// any other characters are not allowed.
+func parseLabelNumber(s string) (int, bool) {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+// Traversal is a pattern used to order the buttons on a Device by their
+// physical position.
+type Traversal uint8
+
+const (
+	// TraverseRows orders buttons left to right, top to bottom.
+	TraverseRows Traversal = iota
+	// TraverseCols orders buttons top to bottom, left to right.
+	TraverseCols
+	// TraverseSnake orders buttons left to right on even rows and right to
+	// left on odd rows, starting at the top.
+	TraverseSnake
+	// TraverseSpiral orders buttons clockwise from the top left button towards
+	// the center of the Device.
+	TraverseSpiral
+)
+
+// ButtonOrder returns the indexes of every button on the Device ordered using
+// the given Traversal, this is useful for effects like wipes that address
+// buttons by their position rather than their index.
+func (t DeviceType) ButtonOrder(traversal Traversal) []int {
+	order := make([]int, 0, t.ButtonCount())
+	add := func(row, col int) {
+		if i, ok := t.ButtonAt(row, col); ok {
+			order = append(order, i)
+		}
+	}
+
+	switch traversal {
+	case TraverseCols:
+		for col := 0; col < t.Cols; col++ {
+			for row := 0; row < t.Rows; row++ {
+				add(row, col)
+			}
+		}
+	case TraverseSnake:
+		for row := 0; row < t.Rows; row++ {
+			for col := 0; col < t.Cols; col++ {
+				if row%2 == 1 {
+					add(row, t.Cols-1-col)
+				} else {
+					add(row, col)
+				}
+			}
+		}
+	case TraverseSpiral:
+		top, bottom, left, right := 0, t.Rows-1, 0, t.Cols-1
+		for top <= bottom && left <= right {
+			for col := left; col <= right; col++ {
+				add(top, col)
+			}
+			for row := top + 1; row <= bottom; row++ {
+				add(row, right)
+			}
+			if top < bottom {
+				for col := right - 1; col >= left; col-- {
+					add(bottom, col)
+				}
+			}
+			if left < right {
+				for row := bottom - 1; row > top; row-- {
+					add(row, left)
+				}
+			}
+			top, bottom, left, right = top+1, bottom-1, left+1, right-1
+		}
+	default:
+		for i := 0; i < t.ButtonCount(); i++ {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
 // GIFT returns the GIFT instance used to transform images for the Device.
 func (t DeviceType) GIFT() *gift.GIFT {
-	return t.ImageFlags.GIFT(t.ImageSize)
+	return t.gift(ImageOptions{})
+}
+
+// gift returns the GIFT instance used to transform images for the Device,
+// including any adjustments specified by the ImageOptions.
+func (t DeviceType) gift(opts ImageOptions) *gift.GIFT {
+	resampling := t.Resampling
+	if opts.Resampling != nil {
+		resampling = opts.Resampling
+	}
+	if resampling == nil {
+		resampling = gift.LanczosResampling
+	}
+
+	g := gift.New(opts.ScaleMode.Filters(t.ImageDimensions(), resampling, opts.Background)...)
+	g.Add(t.ImageFlags.Filters()...)
+	g.Add(opts.Filters()...)
+	return g
 }
 
 // EncodeImage encodes an image to be used with the Stream Deck.
+//
+// ErrNoDisplay is returned if the Device doesn't have a display.
 func (t DeviceType) EncodeImage(img image.Image) ([]byte, error) {
+	return t.EncodeImageWithOptions(img, ImageOptions{})
+}
+
+// EncodeImageWithOptions encodes an image to be used with the Stream Deck,
+// applying any adjustments specified by the ImageOptions after the image has
+// been resized and rotated.
+func (t DeviceType) EncodeImageWithOptions(img image.Image, opts ImageOptions) ([]byte, error) {
+	if !t.HasDisplay() {
+		return nil, fmt.Errorf("%w: %s", ErrNoDisplay, t.Name)
+	}
 	if img == nil {
 		return nil, nil
 	}
+	return t.encodeImage(t.gift(opts), img, opts)
+}
+
+// encodeImage transforms an image using g and encodes it using the format and
+// quality specified by the ImageOptions.
+func (t DeviceType) encodeImage(g *gift.GIFT, img image.Image, opts ImageOptions) ([]byte, error) {
+	// Resize, rotate, and adjust the image, the buffer is only used until the
+	// image has been encoded so it is returned to the pool afterwards.
+	res := getRGBA(g.Bounds(img.Bounds()))
+	defer putRGBA(res)
+	if opts.Dither {
+		// Transform the image at a higher bit depth, so the precision lost
+		// when reducing it to 8 bits per channel can be dithered.
+		wide := image.NewRGBA64(res.Bounds())
+		g.Draw(wide, img)
+		ditherFilter{}.Draw(res, wide, nil)
+	} else {
+		g.Draw(res, img)
+	}
+
+	format := t.ImageFormat
+	if opts.Format != "" {
+		format = opts.Format
+	}
+	quality := t.ImageQuality
+	if opts.Quality != 0 {
+		quality = opts.Quality
+	}
+	if quality == 0 {
+		quality = DefaultImageQuality
+	}
+	return format.EncodeQuality(res, quality)
+}
 
-	g := t.GIFT()
+// ValidateImage returns an error if an encoded image can't be displayed by the
+// Device, either because it isn't encoded using the Device's ImageFormat or
+// because its dimensions don't match the images produced by
+// DeviceType#EncodeImage.
+//
+// Only the image's header is decoded, the image data itself isn't validated.
+func (t DeviceType) ValidateImage(b []byte) error {
+	cfg, err := t.ImageFormat.DecodeConfig(b)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	size := t.ImageDimensions()
+	expected := t.gift(ImageOptions{}).Bounds(image.Rect(0, 0, size.X, size.Y)).Size()
+	if cfg.Width != expected.X || cfg.Height != expected.Y {
+		return fmt.Errorf("%w: image is %dx%d, expected %dx%d", ErrInvalidImage, cfg.Width, cfg.Height, expected.X, expected.Y)
+	}
+	return nil
+}
+
+// DecodeImage decodes an image that was encoded by DeviceType#EncodeImage,
+// undoing any transformations applied by the DeviceType's ImageFlags.
+//
+// A nil image will be decoded as a blank image.
+func (t DeviceType) DecodeImage(b []byte) (image.Image, error) {
+	if !t.HasDisplay() {
+		return nil, fmt.Errorf("%w: %s", ErrNoDisplay, t.Name)
+	}
+	if b == nil {
+		size := t.ImageDimensions()
+		return image.NewRGBA(image.Rect(0, 0, size.X, size.Y)), nil
+	}
 
-	// Resize and rotate the image
+	img, err := t.ImageFormat.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	g := t.ImageFlags.InverseGIFT()
 	res := image.NewRGBA(g.Bounds(img.Bounds()))
 	g.Draw(res, img)
-	return t.ImageFormat.Encode(res)
+	return res, nil
 }
 
 // BrightnessPacketFunc is a function that returns a packet used to change the
-// brightness of a Device.
-type BrightnessPacketFunc func(brightness byte) []byte
+// brightness of a Device, brightness is a percentage in the range
+// [BrightnessMin, BrightnessFull].
+type BrightnessPacketFunc func(brightness uint8) []byte
 
-func brightnessPacketGen1(brightness byte) []byte {
+func brightnessPacketGen1(brightness uint8) []byte {
 	b := make([]byte, 17)
 	b[0] = 0x05
 	b[1] = 0x55
@@ -113,7 +550,7 @@ func brightnessPacketGen1(brightness byte) []byte {
 	return b
 }
 
-func brightnessPacketGen2(brightness byte) []byte {
+func brightnessPacketGen2(brightness uint8) []byte {
 	b := make([]byte, 32)
 	b[0] = 0x03
 	b[1] = 0x08
@@ -138,6 +575,39 @@ func resetPacketGen2() []byte {
 	return b
 }
 
+// SerialNumberFunc is a function that reads the serial number of a Device
+// using a feature report.
+type SerialNumberFunc func(
+	ctx context.Context,
+	r func(context.Context, []byte) (int, error),
+) (string, error)
+
+func serialNumberGen1(ctx context.Context, r func(context.Context, []byte) (int, error)) (string, error) {
+	b := make([]byte, 17)
+	b[0] = 0x03
+	if _, err := r(ctx, b); err != nil {
+		return "", err
+	}
+	return reportString(b[5:]), nil
+}
+
+func serialNumberGen2(ctx context.Context, r func(context.Context, []byte) (int, error)) (string, error) {
+	b := make([]byte, 32)
+	b[0] = 0x06
+	if _, err := r(ctx, b); err != nil {
+		return "", err
+	}
+	return reportString(b[2:]), nil
+}
+
+// reportString converts a null-terminated string from a feature report.
+func reportString(b []byte) string {
+	if i := bytes.IndexByte(b, 0x00); i != -1 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
 // ImageTextureFunc is a function that displays an image for the specified
 // button on a Device.
 type ImageTextureFunc func(
@@ -147,6 +617,91 @@ type ImageTextureFunc func(
 	buffer []byte,
 ) error
 
+// TouchscreenTextureFunc is a function that displays an image on an area of
+// a Device's touchscreen.
+type TouchscreenTextureFunc func(
+	ctx context.Context,
+	w func(context.Context, []byte) (int, error),
+	rect image.Rectangle,
+	buffer []byte,
+) error
+
+// touchscreenTexturePlus displays an image on the touchscreen of a Stream Deck
+// Plus.
+func touchscreenTexturePlus(
+	ctx context.Context,
+	w func(context.Context, []byte) (int, error),
+	rect image.Rectangle,
+	buffer []byte,
+) error {
+	const (
+		// packageSize is the full size of the payload sent to the Stream Deck.
+		packageSize = 1024
+		// headerSize is the size of the header at the beginning of the payload.
+		headerSize = 16
+		// payloadSize is the size available for data in the payload after the header.
+		payloadSize = packageSize - headerSize
+	)
+
+	// Allocate enough memory for the full payload (header + image)
+	payload := make([]byte, packageSize)
+
+	// Set the required data for the payload header
+	payload[0] = 0x02
+	payload[1] = 0x0c
+	binary.LittleEndian.PutUint16(payload[2:4], uint16(rect.Min.X))
+	binary.LittleEndian.PutUint16(payload[4:6], uint16(rect.Min.Y))
+	binary.LittleEndian.PutUint16(payload[6:8], uint16(rect.Dx()))
+	binary.LittleEndian.PutUint16(payload[8:10], uint16(rect.Dy()))
+	// payload[10] = 0x01 if last chunk, 0x00 otherwise.
+	// payload[11:13] = page
+	// payload[13:15] = chunk size
+	payload[15] = 0x00
+
+	// Start at "page" 0 and with the full size of the buffer.
+	page := 0
+	bytesRemaining := len(buffer)
+
+	// Keep iterating until all the data has been sent.
+	for bytesRemaining > 0 {
+		// Stop sending the image if the context has been cancelled or its
+		// deadline has been exceeded.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Get the size of the chunk we will be sending, the maximum size of a
+		// chunk is `payloadSize`.
+		chunkSize := min(bytesRemaining, payloadSize)
+		if chunkSize == bytesRemaining {
+			payload[10] = 0x01
+		} else {
+			payload[10] = 0x00
+		}
+		binary.LittleEndian.PutUint16(payload[11:13], uint16(page))
+		binary.LittleEndian.PutUint16(payload[13:15], uint16(chunkSize))
+
+		// Copy the image into the payload after the header and zero the rest
+		// of the payload if the chunk doesn't fill all the available space.
+		bytesSent := page * payloadSize
+		n := copy(payload[headerSize:], buffer[bytesSent:(bytesSent+chunkSize)])
+		for i := headerSize + n; i < packageSize; i++ {
+			payload[i] = 0
+		}
+
+		// Write the payload
+		if _, err := w(ctx, payload); err != nil {
+			return err
+		}
+
+		// Update the tracking variables
+		bytesRemaining = bytesRemaining - chunkSize
+		page++
+	}
+
+	return nil
+}
+
 // imageTextureOldShared is for gen1 and minis which use the same logic with a
 // different packageSize.
 func imageTextureOldShared(
@@ -182,6 +737,12 @@ func imageTextureOldShared(
 
 	// Keep iterating until all the data has been sent.
 	for bytesRemaining > 0 {
+		// Stop sending the image if the context has been cancelled or its
+		// deadline has been exceeded.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		payload[2] = byte(page)
 
 		// Get the size of the chunk we will be sending, the maximum size of a
@@ -268,6 +829,12 @@ func imageTextureGen2(
 
 	// Keep iterating until all the data has been sent.
 	for bytesRemaining > 0 {
+		// Stop sending the image if the context has been cancelled or its
+		// deadline has been exceeded.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		// Get the size of the chunk we will be sending, the maximum size of a
 		// chunk is `payloadSize`.
 		chunkSize := min(bytesRemaining, payloadSize)
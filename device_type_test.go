@@ -0,0 +1,570 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package streamdeck
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/gift"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// recorder records the packets written to a Device.
+type recorder struct {
+	packets [][]byte
+}
+
+// write satisfies the function used by ImageTextureFunc to write packets.
+func (r *recorder) write(_ context.Context, v []byte) (int, error) {
+	// The packet buffer is re-used for every packet, so it must be copied.
+	r.packets = append(r.packets, bytes.Clone(v))
+	return len(v), nil
+}
+
+// packetLayout describes the header of the packets used to send an image to a
+// Device.
+type packetLayout struct {
+	// header is the size of the header.
+	header int
+	// page returns the index of the page sent by a packet.
+	page func([]byte) int
+	// last returns true if a packet is the last page of an image.
+	last func([]byte) bool
+	// button returns the index of the button an image is sent to.
+	button func([]byte) int
+	// size returns the size of the data sent by a packet, nil if the size
+	// isn't sent in the header.
+	size func([]byte) int
+}
+
+var (
+	// layoutGen1 is used by the original Stream Deck and the Stream Deck Mini.
+	layoutGen1 = packetLayout{
+		header: 16,
+		page:   func(p []byte) int { return int(p[2]) },
+		last:   func(p []byte) bool { return p[4] == 0x01 },
+		button: func(p []byte) int { return int(p[5]) - 1 },
+	}
+	// layoutGen2 is used by every other Device.
+	layoutGen2 = packetLayout{
+		header: 8,
+		page:   func(p []byte) int { return int(binary.LittleEndian.Uint16(p[6:8])) },
+		last:   func(p []byte) bool { return p[3] == 0x01 },
+		button: func(p []byte) int { return int(p[2]) },
+		size:   func(p []byte) int { return int(binary.LittleEndian.Uint16(p[4:6])) },
+	}
+)
+
+// layoutOf returns the packetLayout used by a DeviceType.
+func layoutOf(dt DeviceType) packetLayout {
+	if dt.ButtonOffset == 1 {
+		return layoutGen1
+	}
+	return layoutGen2
+}
+
+// reassemble checks the headers of the packets used to send an image to a
+// button and returns the data sent by the packets.
+func reassemble(l packetLayout, packets [][]byte, button int) ([]byte, error) {
+	if len(packets) == 0 {
+		return nil, fmt.Errorf("no packets were written")
+	}
+	var b []byte
+	for i, p := range packets {
+		if page := l.page(p); page != i {
+			return nil, fmt.Errorf("packet %d: expected page %d, got %d", i, i, page)
+		}
+		if last := l.last(p); last != (i == len(packets)-1) {
+			return nil, fmt.Errorf("packet %d: expected last to be %t", i, !last)
+		}
+		if v := l.button(p); v != button {
+			return nil, fmt.Errorf("packet %d: expected button %d, got %d", i, button, v)
+		}
+		data := p[l.header:]
+		if l.size != nil {
+			size := l.size(p)
+			if size > len(data) {
+				return nil, fmt.Errorf("packet %d: size %d exceeds the packet", i, size)
+			}
+			data = data[:size]
+		}
+		b = append(b, data...)
+	}
+	return b, nil
+}
+
+// testPattern returns an asymmetric image used to check the orientation of
+// images sent to a Device, every quadrant of the image is a different color.
+func testPattern(size image.Point) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	w, h := size.X/2, size.Y/2
+	quadrants := []struct {
+		r image.Rectangle
+		c color.Color
+	}{
+		{image.Rect(0, 0, w, h), color.RGBA{R: 0xff, A: 0xff}},
+		{image.Rect(w, 0, size.X, h), color.RGBA{G: 0xff, A: 0xff}},
+		{image.Rect(0, h, w, size.Y), color.RGBA{B: 0xff, A: 0xff}},
+		{image.Rect(w, h, size.X, size.Y), color.White},
+	}
+	for _, q := range quadrants {
+		draw.Draw(img, q.r, image.NewUniform(q.c), image.Point{}, draw.Src)
+	}
+	return img
+}
+
+// referenceOrientation is the transformation each Device expects images to
+// have, taken from python-elgato-streamdeck's KEY_ROTATION and KEY_FLIP. The
+// image is rotated counter-clockwise before it is flipped.
+var referenceOrientation = map[uint16]struct {
+	rotation     int
+	flipX, flipY bool
+}{
+	0x60: {flipX: true, flipY: true},
+	0x6d: {flipX: true, flipY: true},
+	0x63: {rotation: 90, flipY: true},
+	0x90: {rotation: 90, flipY: true},
+	0x6c: {flipX: true, flipY: true},
+	0x8f: {flipX: true, flipY: true},
+	0x84: {},
+}
+
+// referenceImage returns the test pattern as it should be received by a
+// Device, independently of the DeviceType's ImageFlags.
+func referenceImage(t *testing.T, dt DeviceType) image.Image {
+	ref, ok := referenceOrientation[dt.ProductID]
+	if !ok {
+		t.Fatalf("%s: no reference orientation for product id 0x%02x", dt.Name, dt.ProductID)
+	}
+	g := gift.New()
+	switch ref.rotation {
+	case 0:
+	case 90:
+		g.Add(gift.Rotate90())
+	default:
+		t.Fatalf("%s: unsupported rotation: %d", dt.Name, ref.rotation)
+	}
+	if ref.flipX {
+		g.Add(gift.FlipHorizontal())
+	}
+	if ref.flipY {
+		g.Add(gift.FlipVertical())
+	}
+	src := testPattern(dt.ImageDimensions())
+	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+	g.Draw(dst, src)
+	return dst
+}
+
+// meanDifference returns the mean difference between the color channels of
+// two images of the same size.
+func meanDifference(a, b image.Image) float64 {
+	var sum, n float64
+	ab, bb := a.Bounds(), b.Bounds()
+	for y := 0; y < ab.Dy(); y++ {
+		for x := 0; x < ab.Dx(); x++ {
+			r1, g1, b1, _ := a.At(ab.Min.X+x, ab.Min.Y+y).RGBA()
+			r2, g2, b2, _ := b.At(bb.Min.X+x, bb.Min.Y+y).RGBA()
+			for _, d := range []int{int(r1>>8) - int(r2>>8), int(g1>>8) - int(g2>>8), int(b1>>8) - int(b2>>8)} {
+				if d < 0 {
+					d = -d
+				}
+				sum += float64(d)
+				n++
+			}
+		}
+	}
+	return sum / n
+}
+
+// TestImageOrientation encodes an asymmetric test pattern for every registered
+// DeviceType, sends it through the DeviceType's ImageTextureFunc, and checks
+// the image received by the Device against a golden file.
+//
+// The golden files are generated from referenceOrientation using the -update
+// flag, so an incorrect ImageFlags entry will fail the test.
+func TestImageOrientation(t *testing.T) {
+	for _, dt := range DeviceTypes() {
+		if !dt.HasDisplay() {
+			continue
+		}
+		dt := dt
+		t.Run(fmt.Sprintf("%s/0x%02x", dt.Name, dt.ProductID), func(t *testing.T) {
+			path := filepath.Join("testdata", "orientation", fmt.Sprintf("0x%02x.png", dt.ProductID))
+			if *update {
+				var b bytes.Buffer
+				if err := png.Encode(&b, referenceImage(t, dt)); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			golden, err := png.Decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rawImage, err := dt.EncodeImage(testPattern(dt.ImageDimensions()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var r recorder
+			const button = 3
+			if err := dt.ImageTextureFunc(context.Background(), r.write, button, rawImage); err != nil {
+				t.Fatal(err)
+			}
+			received, err := reassemble(layoutOf(dt), r.packets, button)
+			if err != nil {
+				t.Fatal(err)
+			}
+			img, err := dt.ImageFormat.Decode(received)
+			if err != nil {
+				t.Fatalf("failed to decode the image received by the device: %v", err)
+			}
+
+			if img.Bounds().Size() != golden.Bounds().Size() {
+				t.Fatalf("expected a %v image, got %v", golden.Bounds().Size(), img.Bounds().Size())
+			}
+			// Allow for some difference caused by lossy compression, an image
+			// with the wrong orientation differs by far more than this.
+			if d := meanDifference(img, golden); d > 8 {
+				t.Errorf("image received by the device doesn't match %s, mean difference is %.1f", path, d)
+			}
+		})
+	}
+}
+
+// TestImageTextureChunking checks that images are split into the expected
+// pages at the boundaries of the payload size of each packet, comparing the
+// packets written against a reference implementation.
+func TestImageTextureChunking(t *testing.T) {
+	textures := []struct {
+		name   string
+		fn     ImageTextureFunc
+		layout packetLayout
+		// packageSize is the size of every packet.
+		packageSize int
+	}{
+		{"gen1", imageTextureGen1, layoutGen1, 8191},
+		{"mini", imageTextureMini, layoutGen1, 1024},
+		{"gen2", imageTextureGen2, layoutGen2, 1024},
+	}
+	for _, tt := range textures {
+		tt := tt
+		payloadSize := tt.packageSize - tt.layout.header
+		sizes := []int{
+			1,
+			payloadSize - 1,
+			payloadSize,
+			payloadSize + 1,
+			2*payloadSize - 1,
+			2 * payloadSize,
+			2*payloadSize + 1,
+		}
+		for _, size := range sizes {
+			size := size
+			t.Run(fmt.Sprintf("%s/%d", tt.name, size), func(t *testing.T) {
+				buffer := make([]byte, size)
+				for i := range buffer {
+					// Avoid zero bytes so padding can't be mistaken for data.
+					buffer[i] = byte(i%255) + 1
+				}
+
+				var r recorder
+				const button = 7
+				if err := tt.fn(context.Background(), r.write, button, buffer); err != nil {
+					t.Fatal(err)
+				}
+
+				// The reference implementation sends ceil(size / payloadSize)
+				// pages, only the final page is marked as the last page.
+				pages := (size + payloadSize - 1) / payloadSize
+				if len(r.packets) != pages {
+					t.Fatalf("expected %d packets, got %d", pages, len(r.packets))
+				}
+				for i, p := range r.packets {
+					if len(p) != tt.packageSize {
+						t.Errorf("packet %d: expected %d bytes, got %d", i, tt.packageSize, len(p))
+					}
+				}
+
+				data, err := reassemble(tt.layout, r.packets, button)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// Packets without a size in their header are padded with zeros.
+				if tt.layout.size == nil {
+					padding := data[size:]
+					if !bytes.Equal(padding, make([]byte, len(padding))) {
+						t.Error("expected the last packet to be padded with zeros")
+					}
+					data = data[:size]
+				}
+				if !bytes.Equal(data, buffer) {
+					t.Error("data sent by the packets doesn't match the image")
+				}
+			})
+		}
+	}
+}
+
+// TestImageTextureChunkingEmpty checks that no packets are written for an
+// empty image.
+func TestImageTextureChunkingEmpty(t *testing.T) {
+	for _, fn := range []ImageTextureFunc{imageTextureGen1, imageTextureMini, imageTextureGen2} {
+		var r recorder
+		if err := fn(context.Background(), r.write, 0, nil); err != nil {
+			t.Fatal(err)
+		}
+		if len(r.packets) != 0 {
+			t.Errorf("expected no packets, got %d", len(r.packets))
+		}
+	}
+}
+
+// TestButtonAtRowCol checks that DeviceType#ButtonAt and DeviceType#RowCol
+// round-trip every button of every registered DeviceType, and that buttons
+// outside of the grid are rejected.
+func TestButtonAtRowCol(t *testing.T) {
+	for _, dt := range DeviceTypes() {
+		dt := dt
+		t.Run(dt.Name, func(t *testing.T) {
+			for i := 0; i < dt.ButtonCount(); i++ {
+				row, col := dt.RowCol(i)
+				if row < 0 || row >= dt.Rows || col < 0 || col >= dt.Cols {
+					t.Fatalf("button %d: row %d, col %d is outside of the %dx%d grid", i, row, col, dt.Rows, dt.Cols)
+				}
+				if got, ok := dt.ButtonAt(row, col); !ok || got != i {
+					t.Errorf("button %d: ButtonAt(%d, %d) = %d, %t", i, row, col, got, ok)
+				}
+			}
+
+			for _, rc := range [][2]int{{-1, 0}, {0, -1}, {dt.Rows, 0}, {0, dt.Cols}} {
+				if index, ok := dt.ButtonAt(rc[0], rc[1]); ok {
+					t.Errorf("ButtonAt(%d, %d) = %d, expected it to be out of range", rc[0], rc[1], index)
+				}
+			}
+		})
+	}
+}
+
+// TestDeviceTypeGrid checks the button layout of the registered DeviceTypes
+// against the physical devices.
+func TestDeviceTypeGrid(t *testing.T) {
+	for _, tc := range []struct {
+		productID  uint16
+		rows, cols int
+	}{
+		{0x60, 3, 5},
+		{0x6d, 3, 5},
+		{0x63, 2, 3},
+		{0x90, 2, 3},
+		{0x6c, 4, 8},
+		{0x8f, 4, 8},
+		{0x84, 2, 4},
+	} {
+		dt, ok := DeviceTypeByProductID(tc.productID)
+		if !ok {
+			t.Errorf("0x%02x: device type is not registered", tc.productID)
+			continue
+		}
+		if dt.Rows != tc.rows || dt.Cols != tc.cols {
+			t.Errorf("%s: expected %dx%d buttons, got %dx%d", dt.Name, tc.rows, tc.cols, dt.Rows, dt.Cols)
+		}
+	}
+}
+
+// TestValidate checks that every registered DeviceType is valid and that
+// DeviceTypes missing required information are rejected.
+func TestValidate(t *testing.T) {
+	for _, dt := range deviceTypes {
+		if err := dt.Validate(); err != nil {
+			t.Errorf("%s: %v", dt.Name, err)
+		}
+	}
+
+	base, ok := DeviceTypeByProductID(0x60)
+	if !ok {
+		t.Fatal("stream deck original is not registered")
+	}
+	for _, tc := range []struct {
+		name   string
+		modify func(dt *DeviceType)
+		// want is part of the expected error, empty if the DeviceType is
+		// valid.
+		want string
+	}{
+		{name: "valid", modify: func(*DeviceType) {}},
+		{name: "no serial number", modify: func(dt *DeviceType) { dt.SerialNumberFunc = nil }},
+		{
+			name: "no display",
+			modify: func(dt *DeviceType) {
+				dt.ImageSize = 0
+				dt.ImageTextureFunc = nil
+				dt.BrightnessPacketFunc = nil
+				dt.ImageFormat = ""
+			},
+		},
+		{name: "no product id", modify: func(dt *DeviceType) { dt.ProductID = 0 }, want: "product id"},
+		{name: "no rows", modify: func(dt *DeviceType) { dt.Rows = 0 }, want: "rows and cols"},
+		{name: "no cols", modify: func(dt *DeviceType) { dt.Cols = 0 }, want: "rows and cols"},
+		{name: "negative button offset", modify: func(dt *DeviceType) { dt.ButtonOffset = -1 }, want: "button offset"},
+		{name: "negative dials", modify: func(dt *DeviceType) { dt.Dials = -1 }, want: "dials"},
+		{name: "no reset packet", modify: func(dt *DeviceType) { dt.ResetPacketFunc = nil }, want: "ResetPacketFunc"},
+		{name: "no image size", modify: func(dt *DeviceType) { dt.ImageSize = 0 }, want: "image size"},
+		{name: "no image texture", modify: func(dt *DeviceType) { dt.ImageTextureFunc = nil }, want: "ImageTextureFunc"},
+		{name: "no brightness packet", modify: func(dt *DeviceType) { dt.BrightnessPacketFunc = nil }, want: "BrightnessPacketFunc"},
+		{name: "png format", modify: func(dt *DeviceType) { dt.ImageFormat = PNG }, want: "unsupported image format"},
+		{name: "no format", modify: func(dt *DeviceType) { dt.ImageFormat = "" }, want: "unsupported image format"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dt := base
+			tc.modify(&dt)
+			err := dt.Validate()
+			switch {
+			case tc.want == "" && err != nil:
+				t.Errorf("expected the device type to be valid, got %v", err)
+			case tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)):
+				t.Errorf("expected an error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+// restoreDeviceTypes restores the registered DeviceTypes once the test has
+// finished.
+func restoreDeviceTypes(t *testing.T) {
+	saved := DeviceTypes()
+	t.Cleanup(func() {
+		deviceTypesMx.Lock()
+		deviceTypes = saved
+		deviceTypesMx.Unlock()
+	})
+}
+
+// TestRegisterDeviceType checks that a DeviceType can only be registered once
+// for each ProductID.
+func TestRegisterDeviceType(t *testing.T) {
+	restoreDeviceTypes(t)
+
+	dt, ok := DeviceTypeByProductID(0x60)
+	if !ok {
+		t.Fatal("stream deck original is not registered")
+	}
+	if err := RegisterDeviceType(dt); err == nil {
+		t.Error("expected a duplicate product id to be rejected")
+	}
+
+	dt.Name = "Custom"
+	dt.ProductID = 0x7fff
+	if err := RegisterDeviceType(dt); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := DeviceTypeByProductID(0x7fff); !ok || got.Name != "Custom" {
+		t.Errorf("expected the device type to be registered, got %q", got.Name)
+	}
+	if err := RegisterDeviceType(dt); err == nil {
+		t.Error("expected registering the same device type twice to be rejected")
+	}
+
+	dt.ProductID = 0x7ffe
+	dt.Rows = 0
+	if err := RegisterDeviceType(dt); err == nil {
+		t.Error("expected an invalid device type to be rejected")
+	}
+	if _, ok := DeviceTypeByProductID(0x7ffe); ok {
+		t.Error("expected an invalid device type to not be registered")
+	}
+}
+
+// TestReplaceDeviceType checks that ReplaceDeviceType overwrites the
+// DeviceType with the same ProductID, or registers it if there is none.
+func TestReplaceDeviceType(t *testing.T) {
+	restoreDeviceTypes(t)
+
+	count := len(DeviceTypes())
+	dt, ok := DeviceTypeByProductID(0x60)
+	if !ok {
+		t.Fatal("stream deck original is not registered")
+	}
+	dt.Name = "Patched"
+	if err := ReplaceDeviceType(dt); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := DeviceTypeByProductID(0x60); got.Name != "Patched" {
+		t.Errorf("expected the device type to be replaced, got %q", got.Name)
+	}
+	if n := len(DeviceTypes()); n != count {
+		t.Errorf("expected %d device types, got %d", count, n)
+	}
+
+	dt.ProductID = 0x7fff
+	if err := ReplaceDeviceType(dt); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := DeviceTypeByProductID(0x7fff); !ok {
+		t.Error("expected a new device type to be registered")
+	}
+
+	dt.Rows = 0
+	if err := ReplaceDeviceType(dt); err == nil {
+		t.Error("expected an invalid device type to be rejected")
+	}
+	if got, _ := DeviceTypeByProductID(0x7fff); got.Rows == 0 {
+		t.Error("expected an invalid device type to not replace the registered one")
+	}
+}
+
+// TestDeviceTypesCopy checks that modifying the slice returned by DeviceTypes
+// doesn't change the registered DeviceTypes.
+func TestDeviceTypesCopy(t *testing.T) {
+	dts := DeviceTypes()
+	want := dts[0]
+	dts[0].Name = "Modified"
+	dts[0].Rows = 0
+
+	got, ok := DeviceTypeByProductID(want.ProductID)
+	if !ok || got.Name != want.Name || got.Rows != want.Rows {
+		t.Errorf("expected the registered device type to be unchanged, got %q with %d rows", got.Name, got.Rows)
+	}
+}
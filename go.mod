@@ -4,6 +4,9 @@ go 1.20
 
 require (
 	github.com/disintegration/gift v1.2.1
+	github.com/fsnotify/fsnotify v1.7.0
 	golang.org/x/image v0.15.0
 	golang.org/x/sys v0.17.0
 )
+
+require golang.org/x/text v0.14.0 // indirect
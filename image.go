@@ -24,11 +24,13 @@ package streamdeck
 
 import (
 	"bytes"
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
 	"image/jpeg"
-	_ "image/png"
+	"image/png"
+	"sync"
 
 	"github.com/disintegration/gift"
 	"golang.org/x/image/bmp"
@@ -49,6 +51,16 @@ const (
 	ImageFlagRotate180
 )
 
+// imageFlagOrder is the order image flags are applied in, flipping and
+// rotating an image doesn't produce the same result in every order so a fixed
+// order must be used.
+var imageFlagOrder = []ImageFlags{
+	ImageFlagFlipX,
+	ImageFlagFlipY,
+	ImageFlagRotate90,
+	ImageFlagRotate180,
+}
+
 // imageFlagMap maps ImageFlag options into their associated gift filters used
 // to process images for a Stream Deck device.
 var imageFlagMap = map[ImageFlags]gift.Filter{
@@ -72,15 +84,260 @@ func (f ImageFlags) GIFT(size int) *gift.GIFT {
 			gift.LanczosResampling,
 		),
 	}
-	for k, v := range imageFlagMap {
+	return gift.New(append(filters, f.Filters()...)...)
+}
+
+// Filters returns the gift filters used to apply the flags to an image, the
+// filters are always returned in the same order.
+func (f ImageFlags) Filters() []gift.Filter {
+	var filters []gift.Filter
+	for _, k := range imageFlagOrder {
+		if !f.Has(k) {
+			continue
+		}
+		filters = append(filters, imageFlagMap[k])
+	}
+	return filters
+}
+
+// imageFlagInverseMap maps ImageFlag options into the gift filters used to
+// undo their associated transformation.
+var imageFlagInverseMap = map[ImageFlags]gift.Filter{
+	ImageFlagFlipX:     gift.FlipHorizontal(),
+	ImageFlagFlipY:     gift.FlipVertical(),
+	ImageFlagRotate90:  gift.Rotate270(),
+	ImageFlagRotate180: gift.Rotate180(),
+}
+
+// ImageOptions are used to adjust the appearance of an image before it is
+// displayed on a Stream Deck.
+//
+// Stream Deck devices only support changing the brightness of the entire
+// display, these options allow individual buttons to appear dimmer, brighter,
+// or otherwise adjusted by modifying the image itself.
+type ImageOptions struct {
+	// Brightness adjusts the brightness of the image, the value must be in the
+	// range (-100, 100). A value of 0 leaves the image unchanged.
+	Brightness float32
+
+	// Contrast adjusts the contrast of the image, the value must be in the
+	// range (-100, 100). A value of 0 leaves the image unchanged.
+	Contrast float32
+
+	// Gamma applies gamma correction to the image, a value of 1 leaves the
+	// image unchanged. A value of 0 is treated as unset.
+	Gamma float32
+
+	// Resampling overrides the resampling filter used to resize the image, if
+	// nil the Device's Resampling will be used.
+	Resampling gift.Resampling
+
+	// ScaleMode controls how the image is scaled to the size of a button,
+	// defaults to ScaleStretch.
+	ScaleMode ScaleMode
+
+	// Background is the color used to fill any space not covered by the image
+	// when using ScaleFit, if nil black will be used.
+	Background color.Color
+
+	// Pipeline are additional gift filters applied to the image after it has
+	// been resized, rotated, and adjusted by the other options.
+	Pipeline []gift.Filter
+
+	// Dither applies Floyd–Steinberg dithering when the transformed image is
+	// reduced to 8 bits per channel, this hides the banding that appears in
+	// smooth gradients at the cost of some added noise.
+	Dither bool
+
+	// Quality overrides the quality used to encode JPEG images, in the range
+	// [1, 100]. If zero, the Device's ImageQuality will be used.
+	Quality int
+
+	// Format overrides the ImageFormat used to encode the image, if empty the
+	// Device's ImageFormat will be used.
+	//
+	// This is useful to store processed images using a format like PNG, images
+	// must be encoded using the Device's ImageFormat before they can be
+	// displayed on the Device.
+	Format ImageFormat
+}
+
+// Filters returns the gift filters used to apply the ImageOptions to an image.
+func (o ImageOptions) Filters() []gift.Filter {
+	var filters []gift.Filter
+	if o.Brightness != 0 {
+		filters = append(filters, gift.Brightness(o.Brightness))
+	}
+	if o.Contrast != 0 {
+		filters = append(filters, gift.Contrast(o.Contrast))
+	}
+	if o.Gamma != 0 && o.Gamma != 1 {
+		filters = append(filters, gift.Gamma(o.Gamma))
+	}
+	return append(filters, o.Pipeline...)
+}
+
+// transforms returns true if the ImageOptions change how an image is
+// transformed, rather than only how it is encoded.
+func (o ImageOptions) transforms() bool {
+	return len(o.Filters()) > 0 || o.Resampling != nil || o.ScaleMode != ScaleStretch
+}
+
+// ScaleMode controls how an image is scaled to the size of a button.
+type ScaleMode uint8
+
+const (
+	// ScaleStretch stretches the image to the size of the button, ignoring its
+	// aspect ratio.
+	ScaleStretch ScaleMode = iota
+	// ScaleFit scales the image to fit within the button while preserving its
+	// aspect ratio, any remaining space is filled with a background color.
+	ScaleFit
+	// ScaleFill scales the image to fill the button while preserving its
+	// aspect ratio, any overflow is cropped.
+	ScaleFill
+)
+
+// Filters returns the gift filters used to scale an image to the given size.
+func (m ScaleMode) Filters(size image.Point, resampling gift.Resampling, background color.Color) []gift.Filter {
+	switch m {
+	case ScaleFit:
+		if background == nil {
+			background = color.Black
+		}
+		return []gift.Filter{
+			gift.ResizeToFit(size.X, size.Y, resampling),
+			padFilter{size: size, background: background},
+		}
+	case ScaleFill:
+		return []gift.Filter{gift.ResizeToFill(size.X, size.Y, resampling, gift.CenterAnchor)}
+	default:
+		return []gift.Filter{gift.Resize(size.X, size.Y, resampling)}
+	}
+}
+
+// padFilter is a gift.Filter that centers an image on a background of a fixed
+// size.
+type padFilter struct {
+	size       image.Point
+	background color.Color
+}
+
+var _ gift.Filter = padFilter{}
+
+// Bounds satisfies the gift.Filter interface.
+func (f padFilter) Bounds(image.Rectangle) image.Rectangle {
+	return image.Rect(0, 0, f.size.X, f.size.Y)
+}
+
+// Draw satisfies the gift.Filter interface.
+func (f padFilter) Draw(dst draw.Image, src image.Image, _ *gift.Options) {
+	bounds := dst.Bounds()
+	draw.Draw(dst, bounds, image.NewUniform(f.background), image.Point{}, draw.Src)
+
+	sb := src.Bounds()
+	offset := bounds.Min.Add(image.Pt((f.size.X-sb.Dx())/2, (f.size.Y-sb.Dy())/2))
+	CompositeOver(dst, image.Rectangle{Min: offset, Max: offset.Add(sb.Size())}, src, sb.Min)
+}
+
+// InverseGIFT returns the GIFT instance used to undo the transformations
+// applied by the flags, without resizing the image.
+func (f ImageFlags) InverseGIFT() *gift.GIFT {
+	var filters []gift.Filter
+	// Undo the transformations in the reverse order they were applied.
+	for i := len(imageFlagOrder) - 1; i >= 0; i-- {
+		k := imageFlagOrder[i]
 		if !f.Has(k) {
 			continue
 		}
-		filters = append(filters, v)
+		filters = append(filters, imageFlagInverseMap[k])
 	}
 	return gift.New(filters...)
 }
 
+// ditherFilter is a gift.Filter that uses Floyd–Steinberg dithering to reduce
+// an image to 8 bits per channel, the source image should have a higher bit
+// depth for dithering to have any effect.
+type ditherFilter struct{}
+
+var _ gift.Filter = ditherFilter{}
+
+// Bounds satisfies the gift.Filter interface.
+func (ditherFilter) Bounds(src image.Rectangle) image.Rectangle {
+	return image.Rect(0, 0, src.Dx(), src.Dy())
+}
+
+// Draw satisfies the gift.Filter interface.
+func (ditherFilter) Draw(dst draw.Image, src image.Image, _ *gift.Options) {
+	sb := src.Bounds()
+	db := dst.Bounds()
+	w, h := sb.Dx(), sb.Dy()
+
+	// The error carried to the current and next row for each channel, in
+	// 16-bit units. The rows have a pixel of padding on each side.
+	cur := make([][3]float32, w+2)
+	next := make([][3]float32, w+2)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, a := src.At(sb.Min.X+x, sb.Min.Y+y).RGBA()
+			v := [3]uint32{r, g, b}
+
+			var out [3]uint8
+			for c := 0; c < 3; c++ {
+				want := float32(v[c]) + cur[x+1][c]
+				if want < 0 {
+					want = 0
+				} else if want > 0xffff {
+					want = 0xffff
+				}
+				// Round to the nearest 8-bit value and diffuse the error.
+				q := uint8((want + 128) / 257)
+				out[c] = q
+				e := want - float32(q)*257
+				cur[x+2][c] += e * 7 / 16
+				next[x][c] += e * 3 / 16
+				next[x+1][c] += e * 5 / 16
+				next[x+2][c] += e * 1 / 16
+			}
+			dst.Set(db.Min.X+x, db.Min.Y+y, color.RGBA{R: out[0], G: out[1], B: out[2], A: uint8(a >> 8)})
+		}
+		cur, next = next, cur
+		for i := range next {
+			next[i] = [3]float32{}
+		}
+	}
+}
+
+// rgbaPools contains a *sync.Pool of *image.RGBA buffers for each image size,
+// used to avoid allocating a new buffer every time an image is encoded.
+var rgbaPools sync.Map
+
+// getRGBA returns an *image.RGBA with the given bounds from the pool, the
+// contents of the image are cleared.
+func getRGBA(r image.Rectangle) *image.RGBA {
+	pool, ok := rgbaPools.Load(r)
+	if !ok {
+		pool, _ = rgbaPools.LoadOrStore(r, &sync.Pool{
+			New: func() any {
+				return image.NewRGBA(r)
+			},
+		})
+	}
+	img := pool.(*sync.Pool).Get().(*image.RGBA)
+	for i := range img.Pix {
+		img.Pix[i] = 0
+	}
+	return img
+}
+
+// putRGBA returns an *image.RGBA to the pool, the image must not be used
+// afterwards.
+func putRGBA(img *image.RGBA) {
+	if pool, ok := rgbaPools.Load(img.Rect); ok {
+		pool.(*sync.Pool).Put(img)
+	}
+}
+
 // ImageFormat represents an Image Format used by a Stream Deck Device.
 type ImageFormat string
 
@@ -89,17 +346,49 @@ const (
 	BMP ImageFormat = "BMP"
 	// JPEG is a JPEG ImageFormat.
 	JPEG ImageFormat = "JPEG"
+	// PNG is a PNG ImageFormat.
+	//
+	// No Stream Deck Device accepts PNG images, this format may only be used
+	// to store processed images and can never be sent to a Device.
+	PNG ImageFormat = "PNG"
 )
 
+// pngSignature is the signature at the start of every PNG image.
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// IsWireFormat returns true if the ImageFormat can be sent to a Device.
+func (f ImageFormat) IsWireFormat() bool {
+	switch f {
+	case BMP, JPEG:
+		return true
+	default:
+		return false
+	}
+}
+
+// DefaultImageQuality is the default quality used to encode JPEG images.
+const DefaultImageQuality = 100
+
 // Encode encodes an image using a ImageFormat.
 func (f ImageFormat) Encode(img image.Image) ([]byte, error) {
+	return f.EncodeQuality(img, DefaultImageQuality)
+}
+
+// EncodeQuality encodes an image using a ImageFormat, using the given quality
+// for lossy formats. Lower quality images are smaller and faster to send to a
+// Device.
+func (f ImageFormat) EncodeQuality(img image.Image, quality int) ([]byte, error) {
 	var b bytes.Buffer
 	var err error
 	switch f {
 	case BMP:
 		err = bmp.Encode(&b, img)
 	case JPEG:
-		err = jpeg.Encode(&b, img, &jpeg.Options{Quality: 100})
+		err = jpeg.Encode(&b, img, &jpeg.Options{Quality: quality})
+	case PNG:
+		err = png.Encode(&b, img)
+	default:
+		err = fmt.Errorf("streamdeck: unsupported image format: %q", f)
 	}
 	if err != nil {
 		return nil, err
@@ -107,6 +396,37 @@ func (f ImageFormat) Encode(img image.Image) ([]byte, error) {
 	return b.Bytes(), nil
 }
 
+// Decode decodes an image that was encoded using the ImageFormat.
+func (f ImageFormat) Decode(b []byte) (image.Image, error) {
+	r := bytes.NewReader(b)
+	switch f {
+	case BMP:
+		return bmp.Decode(r)
+	case JPEG:
+		return jpeg.Decode(r)
+	case PNG:
+		return png.Decode(r)
+	default:
+		return nil, fmt.Errorf("streamdeck: unsupported image format: %q", f)
+	}
+}
+
+// DecodeConfig returns the dimensions of an image that was encoded using the
+// ImageFormat without decoding the entire image.
+func (f ImageFormat) DecodeConfig(b []byte) (image.Config, error) {
+	r := bytes.NewReader(b)
+	switch f {
+	case BMP:
+		return bmp.DecodeConfig(r)
+	case JPEG:
+		return jpeg.DecodeConfig(r)
+	case PNG:
+		return png.DecodeConfig(r)
+	default:
+		return image.Config{}, fmt.Errorf("streamdeck: unsupported image format: %q", f)
+	}
+}
+
 // Blank creates and encodes a blank image used to represent an empty button
 // on a Stream Deck.
 func (f ImageFormat) Blank(x, y int) ([]byte, error) {
@@ -0,0 +1,188 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package streamdeck
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/disintegration/gift"
+)
+
+// TestImageFlagsOrder checks that image flags are always applied in the same
+// order, flips before rotations, since the order changes the result.
+func TestImageFlagsOrder(t *testing.T) {
+	const size = 72
+	src := testPattern(image.Pt(size, size))
+	flags := ImageFlags(ImageFlagFlipX | ImageFlagRotate90)
+
+	want := image.NewRGBA(image.Rect(0, 0, size, size))
+	gift.New(
+		gift.Resize(size, size, gift.LanczosResampling),
+		gift.FlipHorizontal(),
+		gift.Rotate90(),
+	).Draw(want, src)
+
+	for i := 0; i < 50; i++ {
+		got := image.NewRGBA(image.Rect(0, 0, size, size))
+		flags.GIFT(size).Draw(got, src)
+		if !bytes.Equal(got.Pix, want.Pix) {
+			t.Fatalf("attempt %d: flags were not applied as flips followed by rotations", i)
+		}
+	}
+}
+
+// TestEncodeImageDeterministic checks that encoding the same image with flags
+// whose order matters always produces identical bytes.
+func TestEncodeImageDeterministic(t *testing.T) {
+	dt, ok := DeviceTypeByProductID(0x63)
+	if !ok {
+		t.Fatal("stream deck mini is not registered")
+	}
+	if dt.ImageFlags != ImageFlagFlipX|ImageFlagRotate90 {
+		t.Fatalf("unexpected image flags for the stream deck mini: %v", dt.ImageFlags)
+	}
+	src := testPattern(dt.ImageDimensions())
+
+	want, err := dt.EncodeImage(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		got, err := dt.EncodeImage(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("attempt %d: encoding the same image produced different bytes", i)
+		}
+	}
+}
+
+// benchmarkImage returns a photo-like image used by the benchmarks, smooth
+// gradients with some noise, so lossy encoders have realistic work to do.
+func benchmarkImage(size image.Point) *image.RGBA {
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	r := rand.New(rand.NewSource(1))
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			noise := r.Intn(32)
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8(x * 223 / size.X),
+				G: uint8(y * 223 / size.Y),
+				B: uint8((x+y)*111/(size.X+size.Y) + noise),
+				A: 0xff,
+			})
+		}
+	}
+	return img
+}
+
+// BenchmarkEncodeImageQuality measures the time to encode an image for the
+// Stream Deck XL at different JPEG qualities, along with the size of the
+// encoded image and the number of packets needed to send it.
+func BenchmarkEncodeImageQuality(b *testing.B) {
+	dt, ok := DeviceTypeByProductID(0x6c)
+	if !ok {
+		b.Fatal("stream deck xl is not registered")
+	}
+	src := benchmarkImage(image.Pt(256, 256))
+
+	for _, quality := range []int{100, 90, 75, 50} {
+		quality := quality
+		b.Run(fmt.Sprintf("quality=%d", quality), func(b *testing.B) {
+			b.ReportAllocs()
+
+			var v []byte
+			for i := 0; i < b.N; i++ {
+				var err error
+				v, err = dt.EncodeImageWithOptions(src, ImageOptions{Quality: quality})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			var r recorder
+			if err := dt.ImageTextureFunc(context.Background(), r.write, 0, v); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(float64(len(v)), "bytes/image")
+			b.ReportMetric(float64(len(r.packets)), "packets/image")
+		})
+	}
+}
+
+// BenchmarkEncodeImage compares encoding an image using a DeviceType, which
+// builds a new GIFT instance every time, to encoding it using a Device, which
+// re-uses its GIFT instance.
+func BenchmarkEncodeImage(b *testing.B) {
+	dt, ok := DeviceTypeByProductID(0x60)
+	if !ok {
+		b.Fatal("stream deck original is not registered")
+	}
+	d := &Device{DeviceType: dt}
+	src := benchmarkImage(image.Pt(256, 256))
+
+	for _, bm := range []struct {
+		name   string
+		encode func(image.Image) ([]byte, error)
+	}{
+		{"DeviceType", dt.EncodeImage},
+		{"Device", d.EncodeImage},
+	} {
+		bm := bm
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := bm.encode(src); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkRGBAPool compares allocating a new buffer for every image to taking
+// one from the pool used by DeviceType#EncodeImage.
+func BenchmarkRGBAPool(b *testing.B) {
+	for _, size := range []int{72, 96} {
+		r := image.Rect(0, 0, size, size)
+		b.Run(fmt.Sprintf("NewRGBA/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = image.NewRGBA(r)
+			}
+		})
+		b.Run(fmt.Sprintf("Pool/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				putRGBA(getRGBA(r))
+			}
+		})
+	}
+}
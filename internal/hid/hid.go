@@ -25,6 +25,7 @@ package hid
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"sync"
 	"time"
@@ -35,6 +36,49 @@ import (
 
 var ErrDeviceAlreadyConnected = errors.New("hid: device already connected")
 
+var (
+	// ErrTimeout is matched by an Error when a transfer times out.
+	ErrTimeout = errors.New("hid: operation timed out")
+	// ErrDisconnected is matched by an Error when the device is no longer
+	// connected.
+	ErrDisconnected = errors.New("hid: device disconnected")
+	// ErrBusy is matched by an Error when the device is in use.
+	ErrBusy = errors.New("hid: device busy")
+)
+
+// Error is returned when an ioctl syscall fails.
+type Error struct {
+	// Request is the ioctl request that failed.
+	Request uint32
+	// Errno is the error returned by the syscall.
+	Errno unix.Errno
+}
+
+// Error satisfies the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("hid: ioctl 0x%x failed: %v", e.Request, e.Errno)
+}
+
+// Unwrap returns the underlying unix.Errno.
+func (e *Error) Unwrap() error {
+	return e.Errno
+}
+
+// Is allows an Error to be matched against ErrTimeout, ErrDisconnected, and
+// ErrBusy using errors.Is.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrTimeout:
+		return e.Errno == unix.ETIMEDOUT
+	case ErrDisconnected:
+		return e.Errno == unix.ENODEV || e.Errno == unix.ENOENT || e.Errno == unix.ESHUTDOWN
+	case ErrBusy:
+		return e.Errno == unix.EBUSY
+	default:
+		return false
+	}
+}
+
 type DeviceInfo struct {
 	VendorID  uint16
 	ProductID uint16
@@ -60,6 +104,26 @@ type USB struct {
 
 	inputPacketSize  uint16
 	outputPacketSize uint16
+
+	// maxAttempts is the maximum number of attempts made for a transfer that
+	// fails with a transient error.
+	maxAttempts int
+}
+
+const (
+	// DefaultMaxAttempts is the default maximum number of attempts made for a
+	// transfer that fails with a transient error.
+	DefaultMaxAttempts = 3
+	// retryBackoff is the delay before retrying a failed transfer, it is
+	// doubled after every attempt.
+	retryBackoff = 5 * time.Millisecond
+)
+
+// SetMaxAttempts sets the maximum number of attempts made for an interrupt
+// transfer or a feature report that fails with EINTR or EAGAIN, a value less
+// than one will use DefaultMaxAttempts.
+func (u *USB) SetMaxAttempts(n int) {
+	u.maxAttempts = n
 }
 
 // Open opens the USB HID device.
@@ -76,8 +140,17 @@ func (u *USB) Open(ctx context.Context) error {
 		return err
 	}
 	u.f = f
-	u.fMx.Unlock()
-	return u.unsafeClaim(ctx)
+	defer u.fMx.Unlock()
+
+	// If the interface can't be claimed, most likely because it was already
+	// claimed by another process, close the file so the device may be opened
+	// again later.
+	if err := u.unsafeClaim(ctx); err != nil {
+		_ = u.f.Close()
+		u.f = nil
+		return err
+	}
+	return nil
 }
 
 // Close closes the device.
@@ -106,6 +179,32 @@ func (u *USB) Info() DeviceInfo {
 	return u.info
 }
 
+// Path returns the path to the device node.
+func (u *USB) Path() string {
+	return u.path
+}
+
+// EndpointIn returns the address of the input endpoint.
+func (u *USB) EndpointIn() uint8 {
+	return u.endpointIn
+}
+
+// EndpointOut returns the address of the output endpoint, zero if the device
+// doesn't have an output endpoint.
+func (u *USB) EndpointOut() uint8 {
+	return u.endpointOut
+}
+
+// InputPacketSize returns the maximum packet size of the input endpoint.
+func (u *USB) InputPacketSize() int {
+	return int(u.inputPacketSize)
+}
+
+// OutputPacketSize returns the maximum packet size of the output endpoint.
+func (u *USB) OutputPacketSize() int {
+	return int(u.outputPacketSize)
+}
+
 func (u *USB) Read(ctx context.Context, v []byte, t time.Duration) (int, error) {
 	n, err := u.intr(ctx, u.endpointIn, v, t)
 	if err == nil {
@@ -117,7 +216,7 @@ func (u *USB) Read(ctx context.Context, v []byte, t time.Duration) (int, error)
 
 func (u *USB) Write(ctx context.Context, v []byte) (int, error) {
 	if u.endpointOut > 0 {
-		return u.intr(ctx, u.endpointOut, v, 1000)
+		return u.intr(ctx, u.endpointOut, v, time.Second)
 	}
 	return u.ctrl(ctx, 0x21, 0x09, 2<<8+0, int(u.info.Interface), v, time.Duration(len(v))*time.Millisecond)
 }
@@ -127,9 +226,33 @@ func (u *USB) GetFeatureReport(ctx context.Context, v []byte) (int, error) {
 	return u.ctrl(ctx, 0xa1, 0x01, (3<<8)+int(v[0]), int(u.info.Interface), v, 0)
 }
 
+func (u *USB) GetInputReport(ctx context.Context, v []byte) (int, error) {
+	// 10100001, GET_REPORT, type*256+id, intf, len, data
+	return u.ctrl(ctx, 0xa1, 0x01, (1<<8)+int(v[0]), int(u.info.Interface), v, 0)
+}
+
+// SendFeatureReport sends a feature report to the device, retrying if the
+// transfer fails with a transient error.
 func (u *USB) SendFeatureReport(ctx context.Context, v []byte) (int, error) {
-	// 00100001, SET_REPORT, type*256+id, intf, len, data
-	return u.ctrl(ctx, 0x21, 0x09, (3<<8)+int(v[0]), int(u.info.Interface), v, 0)
+	return u.retry(ctx, func() (int, error) {
+		// 00100001, SET_REPORT, type*256+id, intf, len, data
+		return u.ctrl(ctx, 0x21, 0x09, (3<<8)+int(v[0]), int(u.info.Interface), v, 0)
+	})
+}
+
+// maxReportDescriptorSize is the size of the buffer used to read a report
+// descriptor, report descriptors for most devices are well under this size.
+const maxReportDescriptorSize = 4096
+
+// ReportDescriptor reads the raw HID report descriptor of the device.
+func (u *USB) ReportDescriptor(ctx context.Context) ([]byte, error) {
+	v := make([]byte, maxReportDescriptorSize)
+	// 10000001, GET_DESCRIPTOR, type*256+index, intf, len, data
+	n, err := u.ctrl(ctx, 0x81, 0x06, USBDescTypeHIDReport<<8, int(u.info.Interface), v, 0)
+	if err != nil {
+		return nil, err
+	}
+	return v[:n], nil
 }
 
 func (u *USB) unsafeClaim(ctx context.Context) error {
@@ -171,7 +294,7 @@ func (u *USB) ctrl(ctx context.Context, rtype, req, val, index int, v []byte, t
 		Len:     uint16(len(v)),
 		Data:    slicePtr(v),
 	}
-	if t != 0 {
+	if t = timeout(ctx, t); t != 0 {
 		s.Timeout = uint32(t.Milliseconds())
 	}
 	if r, err := u.ioctl(ctx, USBDevFSControl, uintptr(unsafe.Pointer(s))); r == -1 {
@@ -181,20 +304,77 @@ func (u *USB) ctrl(ctx context.Context, rtype, req, val, index int, v []byte, t
 	}
 }
 
+// intr performs an interrupt transfer, retrying if the transfer fails with a
+// transient error.
 func (u *USB) intr(ctx context.Context, endpoint uint8, v []byte, t time.Duration) (int, error) {
 	s := &usbFSBulk{
 		Endpoint: uint32(endpoint),
 		Len:      uint32(len(v)),
 		Data:     slicePtr(v),
 	}
-	if t != 0 {
-		s.Timeout = uint32(t.Milliseconds())
+	return u.retry(ctx, func() (int, error) {
+		// Re-calculate the timeout on every attempt, so the context deadline is
+		// still respected when retrying.
+		if d := timeout(ctx, t); d != 0 {
+			s.Timeout = uint32(d.Milliseconds())
+		}
+		return u.ioctl(ctx, USBDevFSBulk, uintptr(unsafe.Pointer(s)))
+	})
+}
+
+// retry calls fn until it succeeds, fails with an error that isn't transient,
+// or the maximum number of attempts has been reached. The delay between
+// attempts is doubled after every attempt.
+func (u *USB) retry(ctx context.Context, fn func() (int, error)) (int, error) {
+	maxAttempts := u.maxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = DefaultMaxAttempts
 	}
-	if r, err := u.ioctl(ctx, USBDevFSBulk, uintptr(unsafe.Pointer(s))); r == -1 {
-		return -1, err
-	} else {
-		return r, nil
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		r, err := fn()
+		if err == nil {
+			return r, nil
+		}
+		if attempt >= maxAttempts || !isTransient(err) {
+			return -1, err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return -1, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// isTransient returns true if err is a transient error that may succeed if the
+// operation is retried.
+func isTransient(err error) bool {
+	return errors.Is(err, unix.EINTR) || errors.Is(err, unix.EAGAIN)
+}
+
+// timeout returns the timeout to use for a transfer, if the context has a
+// deadline that is sooner than t (or t is zero), the time remaining until the
+// deadline will be used instead.
+func timeout(ctx context.Context, t time.Duration) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return t
+	}
+	remaining := time.Until(deadline)
+	if t != 0 && t < remaining {
+		return t
+	}
+	// A timeout of zero disables the timeout entirely, so always wait at least
+	// a millisecond.
+	if remaining < time.Millisecond {
+		return time.Millisecond
 	}
+	return remaining
 }
 
 // unsafeIoctl is like ioctl but is unsafe as it doesn't lock `u.f` before
@@ -204,13 +384,16 @@ func (u *USB) unsafeIoctl(ctx context.Context, req uint32, v uintptr) (int, erro
 	case <-ctx.Done():
 		return 0, ctx.Err()
 	default:
-		r, _, err := unix.Syscall(
+		r, _, errno := unix.Syscall(
 			unix.SYS_IOCTL,
 			u.f.Fd(),
 			uintptr(req),
 			v,
 		)
-		return int(r), err
+		if errno != 0 {
+			return int(r), &Error{Request: req, Errno: errno}
+		}
+		return int(r), nil
 	}
 }
 
@@ -222,12 +405,15 @@ func (u *USB) ioctl(ctx context.Context, req uint32, v uintptr) (int, error) {
 		u.fMx.RLock()
 		fd := u.f.Fd()
 		u.fMx.RUnlock()
-		r, _, err := unix.Syscall(
+		r, _, errno := unix.Syscall(
 			unix.SYS_IOCTL,
 			fd,
 			uintptr(req),
 			v,
 		)
-		return int(r), err
+		if errno != 0 {
+			return int(r), &Error{Request: req, Errno: errno}
+		}
+		return int(r), nil
 	}
 }
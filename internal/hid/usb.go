@@ -25,7 +25,9 @@ package hid
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -52,6 +54,9 @@ const (
 	USBDescTypeInterface = 4
 	USBDescTypeEndpoint  = 5
 	USBDescTypeReport    = 33
+	// USBDescTypeHIDReport is the type of the report descriptor, the
+	// descriptor of type USBDescTypeReport only contains its length.
+	USBDescTypeHIDReport = 34
 )
 
 type usbFSIoctl struct {
@@ -130,7 +135,10 @@ var reDevBusDevice = regexp.MustCompile(`/dev/bus/usb/(\d+)/(\d+)`)
 // Devices returns a slice of USB devices by recursively searching the given
 // directory. If the directory points to a USB device, then it will be returned
 // as a slice of length 1.
-func Devices(dir string) ([]*USB, error) {
+//
+// Nodes that can't be accessed due to missing permissions are skipped, if skip
+// is not nil it will be called with the path and error of every skipped node.
+func Devices(dir string, skip func(path string, err error)) ([]*USB, error) {
 	s, err := os.Lstat(dir)
 	if err != nil {
 		return nil, err
@@ -144,11 +152,12 @@ func Devices(dir string) ([]*USB, error) {
 		return nil, err
 	}
 
-	return devices(dir)
+	return devices(dir, skip)
 }
 
-// devices .
-func devices(dir string) ([]*USB, error) {
+// devices returns the USB devices found by recursively searching a directory,
+// see Devices.
+func devices(dir string, skip func(path string, err error)) ([]*USB, error) {
 	// List contents of the directory.
 	files, err := os.ReadDir(dir)
 	if err != nil {
@@ -160,8 +169,12 @@ func devices(dir string) ([]*USB, error) {
 		path := filepath.Join(dir, f.Name())
 		// If the entry is a directory, then it's a bus, so search for USB devices recursively.
 		if f.IsDir() {
-			devices2, err := Devices(path)
+			devices2, err := Devices(path, skip)
 			if err != nil {
+				if errors.Is(err, fs.ErrPermission) {
+					skipped(skip, path, err)
+					continue
+				}
 				return nil, err
 			}
 			devices = append(devices, devices2...)
@@ -170,6 +183,13 @@ func devices(dir string) ([]*USB, error) {
 
 		device, err := Device(path)
 		if err != nil {
+			// Skip any devices we aren't allowed to access, udev rules usually
+			// only grant access to specific devices and the device we are
+			// looking for may still be accessible.
+			if errors.Is(err, fs.ErrPermission) {
+				skipped(skip, path, err)
+				continue
+			}
 			return nil, err
 		}
 
@@ -182,12 +202,33 @@ func devices(dir string) ([]*USB, error) {
 	return devices, nil
 }
 
-// Device .
+// skipped calls skip, if it is not nil.
+func skipped(skip func(path string, err error), path string, err error) {
+	if skip != nil {
+		skip(path, err)
+	}
+}
+
+// Device returns the USB device described by the descriptor file at path, nil
+// will be returned if the device isn't a HID device or if its descriptors are
+// malformed.
 func Device(path string) (*USB, error) {
 	f, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read device descriptor: %w", err)
 	}
+
+	// A node with malformed descriptors can't be used, skip it rather than
+	// returning an error so it doesn't prevent other devices from being found.
+	device, err := parseDevice(path, f)
+	if err != nil {
+		return nil, nil
+	}
+	return device, nil
+}
+
+// parseDevice parses the descriptors of a USB device.
+func parseDevice(path string, f []byte) (*USB, error) {
 	r := bytes.NewBuffer(f)
 
 	// Filter is used to filter out descriptors in order.
@@ -216,7 +257,7 @@ func Device(path string) (*USB, error) {
 		}
 
 		if n != int(length) || length < 2 {
-			return nil, fmt.Errorf("short read from descriptor: %w", err)
+			return nil, errors.New("short read from descriptor")
 		}
 
 		// Skip descriptor that aren't in the filter.
@@ -0,0 +1,89 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package streamdeck
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+)
+
+// logoColor is the color used to draw the placeholder logo.
+var logoColor = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+
+// ShowLogo displays a placeholder resembling the Elgato logo across all buttons
+// on the Device.
+//
+// Unlike Device#Reset, this doesn't reset any state on the Device, the logo is
+// displayed the same way as any other image. Stream Decks do not provide a way
+// to read their stock logo, so a generated placeholder is used instead.
+func (d *Device) ShowLogo(ctx context.Context) error {
+	if !d.HasDisplay() {
+		return nil
+	}
+
+	size := d.ImageDimensions()
+	logo := drawLogo(image.Pt(d.Cols*size.X, d.Rows*size.Y))
+
+	images := make([][]byte, d.ButtonCount())
+	for i := range images {
+		row, col := d.RowCol(i)
+		x, y := col*size.X, row*size.Y
+		v, err := d.EncodeImage(logo.SubImage(image.Rect(x, y, x+size.X, y+size.Y)))
+		if err != nil {
+			return err
+		}
+		images[i] = v
+	}
+	return d.SetButtonsSlice(ctx, images)
+}
+
+// drawLogo draws a placeholder logo, a stylized "e" centered on a black
+// background of the given size.
+func drawLogo(size image.Point) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+
+	cx, cy := float64(size.X)/2, float64(size.Y)/2
+	outer := math.Min(cx, cy) * 0.8
+	inner := outer * 0.7
+	bar := (outer - inner) / 2
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+			dist := math.Hypot(dx, dy)
+
+			// The ring of the "e", with an opening in the lower right.
+			ring := dist >= inner && dist <= outer && !(dx > 0 && dy > 0 && dy < dx)
+			// The bar across the middle of the "e".
+			middle := dist <= outer && math.Abs(dy) <= bar && dx >= -inner
+
+			if ring || middle {
+				img.SetRGBA(x, y, logoColor)
+			} else {
+				img.SetRGBA(x, y, color.RGBA{A: 0xff})
+			}
+		}
+	}
+	return img
+}
@@ -0,0 +1,61 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package streamdeck
+
+// Metrics receives events used to instrument a Device, like the number of
+// button presses or the amount of data written to the Device.
+//
+// Methods may be called concurrently and are called synchronously while
+// interacting with the Device, so they must be fast and should not block.
+// Implementations can use these events to update counters in a metrics system
+// like Prometheus or expvar.
+type Metrics interface {
+	// ButtonPressed is called whenever a button is pressed.
+	ButtonPressed(index int)
+	// ImageWritten is called whenever an image is written to the Device,
+	// including images written to the touchscreen.
+	ImageWritten(t Transfer)
+	// WriteError is called whenever writing to the Device fails.
+	WriteError(err error)
+	// BrightnessChanged is called whenever the brightness of the Device is
+	// changed.
+	BrightnessChanged(brightness uint8)
+}
+
+// noopMetrics is a Metrics implementation that does nothing, it is used when
+// no Metrics are configured.
+type noopMetrics struct{}
+
+var _ Metrics = noopMetrics{}
+
+// ButtonPressed satisfies the Metrics interface.
+func (noopMetrics) ButtonPressed(int) {}
+
+// ImageWritten satisfies the Metrics interface.
+func (noopMetrics) ImageWritten(Transfer) {}
+
+// WriteError satisfies the Metrics interface.
+func (noopMetrics) WriteError(error) {}
+
+// BrightnessChanged satisfies the Metrics interface.
+func (noopMetrics) BrightnessChanged(uint8) {}
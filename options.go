@@ -0,0 +1,245 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package streamdeck
+
+import (
+	"log"
+	"time"
+
+	"github.com/matthewpi/streamdeck/internal/hid"
+)
+
+// Option is an option used to configure a StreamDeck or a Device when it is
+// opened.
+type Option func(*options)
+
+// options contains the configuration set by Options.
+type options struct {
+	// initialState determines if a press event should be sent for any buttons
+	// that are already held down when the StreamDeck is created.
+	initialState bool
+	// eventPolicy determines how button events are delivered to handlers.
+	eventPolicy EventPolicy
+	// logger is used to log errors and warnings.
+	logger *log.Logger
+	// debugLogger is used to log diagnostic messages, nil disables debug
+	// logging.
+	debugLogger *log.Logger
+	// metrics receives events used to instrument the Device.
+	metrics Metrics
+	// initialBrightness is the brightness set when the StreamDeck is created,
+	// only used if setInitialBrightness is true.
+	initialBrightness    uint8
+	setInitialBrightness bool
+	// wakeTriggersHandler determines if a press or dial event that wakes the
+	// StreamDeck from sleep is also sent to the press or dial handler.
+	wakeTriggersHandler bool
+	// debounce is the minimum time between presses of the same button, zero
+	// disables debouncing.
+	debounce time.Duration
+	// sleepTimeout is how long the StreamDeck may be inactive before it is put
+	// to sleep, zero disables the timeout.
+	sleepTimeout time.Duration
+	// maxWriteAttempts is the maximum number of attempts made for a write that
+	// fails with a transient error, zero uses the default.
+	maxWriteAttempts int
+
+	// path is the path searched for USB devices by Open and OpenSerial.
+	path string
+
+	// resetOnOpen determines if the Device is reset after it is opened.
+	resetOnOpen bool
+	// resetOnClose determines if the Device is reset when it is closed.
+	resetOnClose bool
+}
+
+// newOptions returns the options configured by opts.
+func newOptions(opts []Option) options {
+	o := options{
+		logger: log.Default(),
+
+		path: hid.USBDevBus,
+
+		resetOnOpen:  true,
+		resetOnClose: true,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.logger == nil {
+		o.logger = log.Default()
+	}
+	if o.metrics == nil {
+		o.metrics = noopMetrics{}
+	}
+	return o
+}
+
+// WithInitialState configures the StreamDeck to send a press event for any
+// buttons that are already held down when the StreamDeck is created.
+//
+// By default, events are only sent when a button changes state after the
+// StreamDeck has been created.
+func WithInitialState() Option {
+	return func(o *options) {
+		o.initialState = true
+	}
+}
+
+// WithEventPolicy configures how button events are delivered to handlers, by
+// default EventPolicyBlock is used.
+func WithEventPolicy(policy EventPolicy) Option {
+	return func(o *options) {
+		o.eventPolicy = policy
+	}
+}
+
+// WithLogger configures the logger used by the StreamDeck, by default the
+// standard logger is used.
+func WithLogger(logger *log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithDebugLogger configures a logger used for diagnostic messages, like USB
+// device nodes that were skipped while searching for Stream Decks because they
+// couldn't be accessed.
+//
+// By default, diagnostic messages are discarded.
+func WithDebugLogger(logger *log.Logger) Option {
+	return func(o *options) {
+		o.debugLogger = logger
+	}
+}
+
+// WithMetrics configures the Metrics used to instrument the Device, like
+// counting button presses and the amount of data written to the Device.
+//
+// By default, no metrics are collected.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithInitialBrightness configures the brightness set when the StreamDeck is
+// created, brightness is a percentage in the range
+// [BrightnessMin, BrightnessFull] and will be clamped to that range.
+//
+// By default, the brightness of the Device is left unchanged.
+func WithInitialBrightness(brightness uint8) Option {
+	return func(o *options) {
+		o.initialBrightness = brightness
+		o.setInitialBrightness = true
+	}
+}
+
+// WithSleepTimeout configures the StreamDeck to go to sleep once no buttons
+// have been pressed or released and no dials have been used for the given
+// duration, a duration of zero disables the timeout.
+//
+// By default, the StreamDeck will only sleep when StreamDeck#SetSleeping or
+// StreamDeck#ToggleSleep is called.
+func WithSleepTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.sleepTimeout = d
+	}
+}
+
+// WithWakeTriggersHandler configures the StreamDeck to send a press that wakes
+// it from sleep to the press handler, as well as waking the StreamDeck. Dial
+// events that wake the StreamDeck are sent to the dial handler in the same
+// way.
+//
+// By default, a press that wakes the StreamDeck is swallowed, along with its
+// release, so a button isn't triggered by accident while the display is off.
+// The same applies to dials.
+func WithWakeTriggersHandler() Option {
+	return func(o *options) {
+		o.wakeTriggersHandler = true
+	}
+}
+
+// WithDebounce configures the StreamDeck to ignore a press of a button that
+// occurs within d of the previous press of the same button, along with its
+// release. This prevents a noisy button from firing twice for a single press.
+//
+// By default, presses are not debounced.
+func WithDebounce(d time.Duration) Option {
+	return func(o *options) {
+		o.debounce = d
+	}
+}
+
+// WithMaxWriteAttempts configures the maximum number of attempts made for a
+// write to the Device that is interrupted (EINTR) or would block (EAGAIN), this
+// includes images, brightness changes, and resets. Writes are retried with a
+// short backoff while the context allows it.
+//
+// By default, up to three attempts are made.
+func WithMaxWriteAttempts(n int) Option {
+	return func(o *options) {
+		o.maxWriteAttempts = n
+	}
+}
+
+// WithPath configures the path searched for Stream Deck Devices by Open,
+// OpenSerial, and New, by default "/dev/bus/usb" is searched.
+//
+// The path may be a directory containing USB devices, like a device tree
+// mounted into a container, or a specific device node such as
+// "/dev/bus/usb/003/004" in which case no other devices are enumerated.
+func WithPath(path string) Option {
+	return func(o *options) {
+		o.path = path
+	}
+}
+
+// WithoutResetOnOpen prevents the Device from being reset after it is opened,
+// leaving any images already displayed on the Device.
+//
+// This is useful when taking over a Device that was already configured by
+// another process.
+func WithoutResetOnOpen() Option {
+	return func(o *options) {
+		o.resetOnOpen = false
+	}
+}
+
+// WithoutResetOnClose prevents the Device from being reset and its brightness
+// from being restored when it is closed, leaving the Device as-is.
+func WithoutResetOnClose() Option {
+	return func(o *options) {
+		o.resetOnClose = false
+	}
+}
+
+// logSkipped logs a USB device node that was skipped while searching for
+// Stream Decks to the debug logger.
+func (o options) logSkipped(path string, err error) {
+	if o.debugLogger == nil {
+		return
+	}
+	o.debugLogger.Printf("streamdeck: skipped %s: %v\n", path, err)
+}
@@ -0,0 +1,59 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package streamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// Snapshot returns an image of what is currently displayed on the Device, with
+// the image of each button tiled in the same layout as the buttons.
+//
+// Stream Decks do not provide a way to read back what they are displaying, the
+// snapshot is built from the last image written to each button. Any buttons
+// that haven't been written to since the Device was reset are blank.
+func (d *Device) Snapshot() (image.Image, error) {
+	d.writeMx.Lock()
+	displayed := make([][]byte, d.ButtonCount())
+	copy(displayed, d.displayed)
+	d.writeMx.Unlock()
+
+	size := d.ImageDimensions()
+	img := image.NewRGBA(image.Rect(0, 0, d.Cols*size.X, d.Rows*size.Y))
+	if !d.HasDisplay() {
+		return img, nil
+	}
+	for i, v := range displayed {
+		tile, err := d.DecodeImage(v)
+		if err != nil {
+			return nil, fmt.Errorf("streamdeck: failed to decode image for button %d: %w", i, err)
+		}
+
+		row, col := d.RowCol(i)
+		min := image.Pt(col*size.X, row*size.Y)
+		draw.Draw(img, image.Rectangle{Min: min, Max: min.Add(size)}, tile, tile.Bounds().Min, draw.Src)
+	}
+	return img, nil
+}
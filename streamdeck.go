@@ -24,15 +24,57 @@ package streamdeck
 
 import (
 	"context"
+	"fmt"
 	"image"
+	"log"
+	"math"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"github.com/disintegration/gift"
+)
+
+const (
+	// eventBufferSize is the number of button events that may be buffered
+	// before the USB read loop or handlers are blocked.
+	eventBufferSize = 64
+	// eventWorkers is the number of handlers that may run concurrently when
+	// using EventPolicyQueue or EventPolicyDrop.
+	eventWorkers = 4
 )
 
+// EventPolicy determines how button events are delivered to handlers.
+type EventPolicy uint8
+
+const (
+	// EventPolicyBlock calls handlers one at a time in the order events are
+	// received, a slow handler will delay any events that follow it.
+	EventPolicyBlock EventPolicy = iota
+	// EventPolicyQueue calls handlers concurrently using a bounded pool of
+	// workers, events are queued while all the workers are busy. Handlers may
+	// be called out of order.
+	EventPolicyQueue
+	// EventPolicyDrop is like EventPolicyQueue except that events will be
+	// dropped rather than queued if the queue is full.
+	EventPolicyDrop
+)
+
+// handlerCall is a call to a button press or release handler.
+type handlerCall struct {
+	fn    func(context.Context, int) error
+	index int
+}
+
 // StreamDeck represents an Elgato Stream Deck.
 type StreamDeck struct {
 	// device is a wrapper of the underlying USB HID Device.
 	device *Device
+	// stateMx is held while changing the brightness or sleep state, so the
+	// brightness written to the Device always matches the brightness and
+	// isSleeping fields. The fields are atomic so they may be read without
+	// holding the mutex.
+	stateMx sync.Mutex
 	// brightness is the Stream Deck's target brightness. brightness is not
 	// always guaranteed to be the Stream Deck's current brightness, like if
 	// the Stream Deck is sleeping for example.
@@ -46,28 +88,118 @@ type StreamDeck struct {
 	// presses will continue functioning.
 	isSleeping atomic.Bool
 
+	// initialState determines if a press event should be sent for any buttons
+	// that are already held down when the Stream Deck is opened.
+	initialState bool
+
+	// eventPolicy determines how button events are delivered to handlers.
+	eventPolicy EventPolicy
+	// resetOnClose determines if the Device is reset when the Stream Deck is
+	// closed.
+	resetOnClose bool
+	// sleepTimeout is how long the Stream Deck may be inactive before it is
+	// put to sleep, zero disables the timeout.
+	sleepTimeout time.Duration
+	// wakeTriggersHandler determines if a press or dial event that wakes the
+	// Stream Deck from sleep is also sent to the press or dial handler.
+	wakeTriggersHandler bool
+	// debounce is the minimum time between presses of the same button, zero
+	// disables debouncing.
+	debounce time.Duration
+	// calls is used to send handler calls to workers when not using
+	// EventPolicyBlock.
+	calls chan handlerCall
+
+	// logger is used to log errors and warnings.
+	logger *log.Logger
+
+	// sleepHandlerMx is a mutex used to protect the sleepHandler field.
+	sleepHandlerMx sync.Mutex
+	// sleepHandler is the callback that is called whenever the Stream Deck
+	// goes to sleep or wakes up.
+	sleepHandler func(sleeping bool)
+
+	// errorHandlerMx is a mutex used to protect the errorHandler field.
+	errorHandlerMx sync.Mutex
+	// errorHandler is the callback that is called whenever an error occurs
+	// while handling a button event.
+	errorHandler func(error)
+
+	// viewMx is a mutex used to protect the view, viewCtx, and viewCancel
+	// fields.
+	viewMx sync.Mutex
+	// view is the View that is currently displayed.
+	view View
+	// viewCtx is the context passed to the current View.
+	viewCtx context.Context
+	// viewCancel is used to cancel the context of the current View.
+	viewCancel context.CancelFunc
+
+	// closed is true once the Stream Deck has been closed.
+	closed atomic.Bool
+
 	// cancel is used to cancel the button press and callback goroutines.
 	cancel context.CancelFunc
-	// ch is the internal channel used to receive button press events.
-	ch chan int
+	// ch is the internal channel used to receive button events.
+	ch chan buttonEvent
 
-	// pressHandlerMx is a mutex used to protect the pressHandler field.
+	// pressHandlerMx is a mutex used to protect the pressHandler and
+	// releaseHandler fields.
 	pressHandlerMx sync.Mutex
 	// pressHandler is the callback that is called whenever a button is pressed.
 	pressHandler func(context.Context, int) error
+	// releaseHandler is the callback that is called whenever a button is
+	// released.
+	releaseHandler func(context.Context, int) error
+
+	// waitersMx is a mutex used to protect the waiters field.
+	waitersMx sync.Mutex
+	// waiters are notified of the next button press, used by
+	// StreamDeck#WaitForPress.
+	waiters []chan int
+
+	// dialCh is the internal channel used to receive dial events, nil if the
+	// Device doesn't have any dials.
+	dialCh chan DialEvent
+	// dialHandlerMx is a mutex used to protect the dialHandler field.
+	dialHandlerMx sync.Mutex
+	// dialHandler is the callback that is called whenever a dial is rotated,
+	// pressed, or released.
+	dialHandler func(context.Context, DialEvent) error
+
+	// eventsMx is a mutex used to protect the events and eventsClosed fields.
+	eventsMx sync.Mutex
+	// events are the channels returned by StreamDeck#Events.
+	events []chan ButtonEvent
+	// eventsClosed is true once the channels in events have been closed.
+	eventsClosed bool
+}
+
+// ButtonEvent represents a button being pressed or released.
+type ButtonEvent struct {
+	// Index of the button.
+	Index int
+	// Pressed is true if the button was pressed, false if it was released.
+	Pressed bool
+	// Time is when the event was received from the Device, events for buttons
+	// that changed state at the same time share the same Time.
+	Time time.Time
+	// Chord contains the index of every button pressed at the same time as
+	// this button, including Index. It is only set for press events when more
+	// than one button was pressed at once and must not be modified.
+	Chord []int
 }
 
 // New opens a connection to a Stream Deck and provides a user-friendly wrapper
 // that makes interacting with the Stream Deck easier and more convenient.
-func New(ctx context.Context) (*StreamDeck, error) {
-	device, err := Open(ctx)
+//
+// ErrDeviceNotFound is returned if no Stream Deck could be found.
+func New(ctx context.Context, opts ...Option) (*StreamDeck, error) {
+	device, err := Open(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
-	if device == nil {
-		return nil, err
-	}
-	return NewFromDevice(ctx, device)
+	return NewFromDevice(ctx, device, opts...)
 }
 
 // NewFromDevice creates a new Stream Deck from an existing Device, most users
@@ -76,29 +208,93 @@ func New(ctx context.Context) (*StreamDeck, error) {
 // This function can be useful if you have a specific USB device you want to use
 // like if you want to connect to multiple Stream Decks or use a specific device
 // that is not auto-detected correctly.
-func NewFromDevice(ctx context.Context, device *Device) (*StreamDeck, error) {
+func NewFromDevice(ctx context.Context, device *Device, opts ...Option) (*StreamDeck, error) {
+	if device == nil {
+		return nil, ErrDeviceNotFound
+	}
+	o := newOptions(opts)
+
 	ctx, cancel := context.WithCancel(ctx)
 	s := &StreamDeck{
 		device: device,
 
+		initialState: o.initialState,
+		eventPolicy:  o.eventPolicy,
+		resetOnClose: o.resetOnClose,
+		sleepTimeout: o.sleepTimeout,
+
+		wakeTriggersHandler: o.wakeTriggersHandler,
+		debounce:            o.debounce,
+
 		cancel: cancel,
-		ch:     make(chan int),
+		ch:     make(chan buttonEvent, eventBufferSize),
+
+		logger: o.logger,
 	}
 
-	// TODO: is this always wanted?
-	s.brightness.Store(uint32(BrightnessFull))
+	if o.setInitialBrightness {
+		brightness := clampBrightness(o.initialBrightness)
+		if err := device.SetBrightness(ctx, brightness); err != nil {
+			cancel()
+			return nil, err
+		}
+		s.brightness.Store(uint32(brightness))
+	} else {
+		// TODO: is this always wanted?
+		s.brightness.Store(uint32(BrightnessFull))
+	}
 
-	go s.device.buttonPressListener(ctx, s.ch)
+	if s.eventPolicy != EventPolicyBlock {
+		s.calls = make(chan handlerCall, eventBufferSize)
+		for i := 0; i < eventWorkers; i++ {
+			go s.handlerWorker(ctx)
+		}
+	}
+	if device.HasDials() {
+		s.dialCh = make(chan DialEvent, eventBufferSize)
+	}
+	go s.device.buttonPressListener(ctx, s.ch, s.dialCh, s.initialState)
 	go s.buttonCallbackListener(ctx)
 
 	return s, nil
 }
 
 // Close stops the event listeners and closes the underlying connection to the
-// Stream Deck device.
+// Stream Deck device. The connection is closed even if resetting the device
+// fails.
+//
+// Calling Close on a Stream Deck that is already closed does nothing.
 func (s *StreamDeck) Close(ctx context.Context) error {
+	if !s.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	s.viewMx.Lock()
+	if s.viewCancel != nil {
+		s.viewCancel()
+		s.viewCancel = nil
+	}
+	s.view = nil
+	s.viewCtx = nil
+	s.viewMx.Unlock()
+
 	s.cancel()
-	return s.device.Close(ctx)
+	s.closeEvents()
+	reset := s.resetOnClose && s.device.resetOnClose
+	if err := s.device.close(ctx, reset); err != nil {
+		return err
+	}
+	if !reset {
+		return nil
+	}
+
+	// Closing the device restores it to full brightness, update the stored
+	// state to match the device.
+	s.stateMx.Lock()
+	s.brightness.Store(uint32(BrightnessFull))
+	s.isSleeping.Store(false)
+	s.stateMx.Unlock()
+	return nil
 }
 
 // Device returns the underlying Stream Deck device.
@@ -106,32 +302,60 @@ func (s *StreamDeck) Device() *Device {
 	return s.device
 }
 
+// ClearButton clears a specific button on the Stream Deck.
+func (s *StreamDeck) ClearButton(ctx context.Context, index int) error {
+	return s.device.ClearButton(ctx, index)
+}
+
 // Brightness returns the target brightness of the Stream Deck. This will not
 // return 0 if the Stream Deck is sleeping. To check if the Stream Deck is
 // sleeping use StreamDeck#IsSleeping().
+//
+// Stream Deck devices do not provide a way to read their current brightness,
+// the value returned is the last brightness set using this library.
 func (s *StreamDeck) Brightness() uint8 {
 	return uint8(s.brightness.Load())
 }
 
-// SetBrightness sets the brightness of the Stream Deck.
+// SetBrightness sets the brightness of the Stream Deck, brightness is a
+// percentage in the range [BrightnessMin, BrightnessFull] and will be clamped
+// to that range.
+//
+// If the Stream Deck is sleeping, the brightness will be applied once the
+// Stream Deck wakes up.
+//
+// This method is safe to call concurrently with any other method that changes
+// the brightness or sleep state, each change is applied as a unit.
 func (s *StreamDeck) SetBrightness(ctx context.Context, brightness uint8) error {
-	if brightness < BrightnessMin {
-		brightness = BrightnessMin
-	}
-	if brightness > BrightnessFull {
-		brightness = BrightnessFull
-	}
-	// Only update the Stream Deck's actual brightness if it isn't sleeping.
+	brightness = clampBrightness(brightness)
+
+	s.stateMx.Lock()
+	defer s.stateMx.Unlock()
+
+	// Only update the Stream Deck's actual brightness if it isn't sleeping,
+	// the target brightness is always stored so it is used when the Stream
+	// Deck wakes up.
 	if !s.IsSleeping() {
 		if err := s.setBrightness(ctx, brightness); err != nil {
 			return err
 		}
 	}
-	// Always persist the new target brightness.
 	s.brightness.Store(uint32(brightness))
 	return nil
 }
 
+// SetBrightnessPercent sets the brightness of the Stream Deck using a
+// fraction in the range [0, 1].
+func (s *StreamDeck) SetBrightnessPercent(ctx context.Context, percent float64) error {
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 1 {
+		percent = 1
+	}
+	return s.SetBrightness(ctx, uint8(math.Round(percent*float64(BrightnessFull))))
+}
+
 // setBrightness sets the brightness of the Stream Deck.
 func (s *StreamDeck) setBrightness(ctx context.Context, brightness uint8) error {
 	if err := s.device.SetBrightness(ctx, brightness); err != nil {
@@ -147,30 +371,79 @@ func (s *StreamDeck) IsSleeping() bool {
 
 // SetSleeping sets whether the Stream Deck is sleeping or not.
 func (s *StreamDeck) SetSleeping(ctx context.Context, sleeping bool) error {
+	s.stateMx.Lock()
+	changed, err := s.setSleeping(ctx, sleeping)
+	s.stateMx.Unlock()
+	if err != nil {
+		return err
+	}
+	if changed {
+		s.notifySleep(sleeping)
+	}
+	return nil
+}
+
+// setSleeping sets whether the Stream Deck is sleeping or not, returning true
+// if the sleep state changed. StreamDeck#stateMx must be held by the caller.
+func (s *StreamDeck) setSleeping(ctx context.Context, sleeping bool) (bool, error) {
 	newBrightness := s.Brightness()
 	if sleeping {
 		newBrightness = BrightnessMin
 	}
 	if err := s.setBrightness(ctx, newBrightness); err != nil {
-		return err
+		return false, err
 	}
+	return s.isSleeping.Swap(sleeping) != sleeping, nil
+}
 
-	// Update the isSleeping state only after successfully changing the
-	// Stream Deck's brightness.
-	s.isSleeping.Store(sleeping)
-	return nil
+// notifySleep calls the sleep handler after the sleep state has changed, it
+// must be called without holding StreamDeck#stateMx so the handler may change
+// the brightness or sleep state.
+func (s *StreamDeck) notifySleep(sleeping bool) {
+	s.sleepHandlerMx.Lock()
+	sleepHandler := s.sleepHandler
+	s.sleepHandlerMx.Unlock()
+	if sleepHandler != nil {
+		sleepHandler(sleeping)
+	}
 }
 
-// ToggleSleep toggles the sleep state for the Stream Deck.
+// SetSleepHandler sets the handler called whenever the Stream Deck goes to
+// sleep or wakes up, including when it is put to sleep after inactivity or
+// woken up by a button press.
+//
+// The handler is only called when the sleep state changes, it is called
+// synchronously by the goroutine that changed the state and should not block.
+func (s *StreamDeck) SetSleepHandler(fn func(sleeping bool)) {
+	s.sleepHandlerMx.Lock()
+	defer s.sleepHandlerMx.Unlock()
+
+	s.sleepHandler = fn
+}
+
+// ToggleSleep toggles the sleep state for the Stream Deck, returning the new
+// sleep state.
 func (s *StreamDeck) ToggleSleep(ctx context.Context) (bool, error) {
-	if err := s.SetSleeping(ctx, !s.IsSleeping()); err != nil {
+	s.stateMx.Lock()
+	sleeping := !s.IsSleeping()
+	changed, err := s.setSleeping(ctx, sleeping)
+	s.stateMx.Unlock()
+	if err != nil {
 		return false, err
 	}
-	return s.IsSleeping(), nil
+	if changed {
+		s.notifySleep(sleeping)
+	}
+	return sleeping, nil
 }
 
 // SetHandler sets the button press handler used by the end-user to handle press
-// events.
+// events, a nil handler stops press events from being handled.
+//
+// The handler may be changed at any time, including from within a handler.
+// The handler is chosen when a press is received, so a press that was already
+// dispatched, or queued when using EventPolicyQueue, runs the previous handler
+// to completion and only presses received afterwards use the new handler.
 func (s *StreamDeck) SetHandler(fn func(context.Context, int) error) {
 	s.pressHandlerMx.Lock()
 	defer s.pressHandlerMx.Unlock()
@@ -178,40 +451,431 @@ func (s *StreamDeck) SetHandler(fn func(context.Context, int) error) {
 	s.pressHandler = fn
 }
 
+// SetView displays a View on the Stream Deck.
+//
+// The context passed to the previous View's Apply method is cancelled, stopping
+// any animations it started. If the View implements PressHandler, it will be
+// set as the Stream Deck's button press handler, likewise for ReleaseHandler
+// and the button release handler. Otherwise the handler is cleared, so presses
+// no longer reach the previous View.
+func (s *StreamDeck) SetView(ctx context.Context, v View) error {
+	s.viewMx.Lock()
+	defer s.viewMx.Unlock()
+
+	if s.viewCancel != nil {
+		s.viewCancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.view = v
+	s.viewCtx = ctx
+	s.viewCancel = cancel
+
+	if h, ok := v.(PressHandler); ok {
+		s.SetHandler(h.Handle)
+	} else {
+		s.SetHandler(nil)
+	}
+	if h, ok := v.(ReleaseHandler); ok {
+		s.SetReleaseHandler(h.HandleRelease)
+	} else {
+		s.SetReleaseHandler(nil)
+	}
+	return v.Apply(ctx)
+}
+
+// Reset resets the Stream Deck, restoring its initial state displaying the
+// Elgato logo.
+//
+// If the View set using StreamDeck#SetView implements ResetHandler, it will be
+// notified after the Stream Deck has been reset.
+func (s *StreamDeck) Reset(ctx context.Context) error {
+	if err := s.device.Reset(ctx); err != nil {
+		return err
+	}
+
+	s.viewMx.Lock()
+	view := s.view
+	viewCtx := s.viewCtx
+	s.viewMx.Unlock()
+
+	if h, ok := view.(ResetHandler); ok {
+		return h.OnReset(viewCtx)
+	}
+	return nil
+}
+
+// SetErrorHandler sets the handler called whenever an error occurs while
+// handling a button event, like if a press handler returns an error or if the
+// Stream Deck fails to wake from sleep.
+//
+// If no error handler is set, errors will be logged.
+func (s *StreamDeck) SetErrorHandler(fn func(error)) {
+	s.errorHandlerMx.Lock()
+	defer s.errorHandlerMx.Unlock()
+
+	s.errorHandler = fn
+}
+
+// Logger returns the logger used by the Stream Deck.
+func (s *StreamDeck) Logger() *log.Logger {
+	return s.logger
+}
+
+// SetReleaseHandler sets the button release handler used by the end-user to
+// handle release events.
+//
+// A release event is only sent for a button if its press event was sent to the
+// press handler, releasing a button that woke the Stream Deck from sleep will
+// not send a release event unless WithWakeTriggersHandler is used.
+//
+// The handler may be changed at any time, the same as StreamDeck#SetHandler.
+func (s *StreamDeck) SetReleaseHandler(fn func(context.Context, int) error) {
+	s.pressHandlerMx.Lock()
+	defer s.pressHandlerMx.Unlock()
+
+	s.releaseHandler = fn
+}
+
+// SetDialHandler sets the handler called whenever a dial on the Stream Deck is
+// rotated, pressed, or released.
+//
+// Dial events reset the sleep timeout the same as button events, see
+// WithSleepTimeout. Dial events received while the Stream Deck is sleeping will
+// wake it up and are not propagated to the handler.
+//
+// The handler is called by the goroutine that handles button events, so it
+// should not block.
+func (s *StreamDeck) SetDialHandler(fn func(context.Context, DialEvent) error) {
+	s.dialHandlerMx.Lock()
+	defer s.dialHandlerMx.Unlock()
+
+	s.dialHandler = fn
+}
+
+// SetRawInputHandler sets a handler called with every unparsed input report
+// read from the Stream Deck, see Device#SetRawInputHandler.
+func (s *StreamDeck) SetRawInputHandler(fn func(report []byte)) {
+	s.device.SetRawInputHandler(fn)
+}
+
 // ProcessImage processes an image to be used with the Stream Deck.
+//
+// The processed image is never modified once it has been returned, so the same
+// image may be displayed on any number of buttons using Device#SetButton or a
+// single button.Image, without processing the image again for each button.
+//
+// ErrNoDisplay is returned if the Stream Deck doesn't have a display.
 func (s *StreamDeck) ProcessImage(img image.Image) ([]byte, error) {
-	return s.device.EncodeImage(img)
+	return s.ProcessImageWithOptions(img, ImageOptions{})
+}
+
+// ProcessImageWithOptions processes an image to be used with the Stream Deck,
+// applying the adjustments specified by the ImageOptions.
+//
+// This is useful to make individual buttons appear dimmer or brighter, as the
+// Stream Deck only supports changing the brightness of the entire display.
+func (s *StreamDeck) ProcessImageWithOptions(img image.Image, opts ImageOptions) ([]byte, error) {
+	return s.device.EncodeImageWithOptions(img, opts)
+}
+
+// ProcessImageWith processes an image to be used with the Stream Deck, applying
+// the given gift filters after the image has been resized and rotated.
+//
+// This allows individual buttons to use their own processing, like applying a
+// color overlay, while still producing an image the Stream Deck can display.
+func (s *StreamDeck) ProcessImageWith(img image.Image, filters ...gift.Filter) ([]byte, error) {
+	return s.ProcessImageWithOptions(img, ImageOptions{Pipeline: filters})
 }
 
 // buttonCallbackListener listens for events to be sent over the StreamDeck#ch
-// channel and calls StreamDeck#pressHandler with the data.
+// and StreamDeck#dialCh channels and calls StreamDeck#pressHandler,
+// StreamDeck#releaseHandler, or StreamDeck#dialHandler with the data.
+//
+// If a sleep timeout is configured, the Stream Deck will be put to sleep once
+// no button or dial events have been received for the duration of the
+// timeout.
 func (s *StreamDeck) buttonCallbackListener(ctx context.Context) error {
+	// swallowed tracks buttons whose press woke the Stream Deck from sleep, the
+	// release event for these buttons will not be propagated.
+	swallowed := make(map[int]bool)
+	// swallowedDials tracks dials whose press woke the Stream Deck from sleep,
+	// the same as swallowed does for buttons.
+	swallowedDials := make(map[int]bool)
+	// lastPress tracks when each button was last pressed, used to debounce
+	// presses.
+	lastPress := make(map[int]time.Time)
+
+	// timeout fires once the Stream Deck has been inactive for longer than the
+	// sleep timeout, it is nil if the sleep timeout is disabled.
+	var (
+		timer   *time.Timer
+		timeout <-chan time.Time
+	)
+	if s.sleepTimeout > 0 {
+		timer = time.NewTimer(s.sleepTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	// resetTimer restarts the sleep timeout after any activity.
+	resetTimer := func() {
+		if timer == nil {
+			return
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(s.sleepTimeout)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case index := <-s.ch:
+		case <-timeout:
+			// Keep the timer running so the Stream Deck will go back to sleep
+			// if it is woken up without any buttons being pressed.
+			timer.Reset(s.sleepTimeout)
+			if s.IsSleeping() {
+				continue
+			}
+			if err := s.SetSleeping(ctx, true); err != nil {
+				s.handleError(fmt.Errorf("streamdeck: failed to sleep after inactivity: %w", err))
+			}
+		case event := <-s.dialCh:
+			resetTimer()
+
+			if event.Kind == DialRelease {
+				if swallowedDials[event.Index] {
+					delete(swallowedDials, event.Index)
+					continue
+				}
+				s.handleDial(ctx, event)
+				continue
+			}
+
+			// Dial activity wakes the Stream Deck the same as a button press.
+			if !s.wake(ctx) {
+				if event.Kind == DialPress {
+					swallowedDials[event.Index] = true
+				}
+				continue
+			}
+			s.handleDial(ctx, event)
+		case event := <-s.ch:
+			resetTimer()
+
+			// Copy the handlers so they may be swapped while this event is
+			// being handled, the handlers are bound to the event from here on.
 			s.pressHandlerMx.Lock()
 			pressHandler := s.pressHandler
+			releaseHandler := s.releaseHandler
 			s.pressHandlerMx.Unlock()
 
-			// Disable sleep whenever a button is pressed, another button press
-			// is required to trigger the underlying press handler.
-			if s.IsSleeping() {
-				// TODO: clients may use a inactivity timeout to toggle sleep,
-				// we may want to send an event when sleep is disabled or
-				// handle the inactivity timeout in this library natively.
+			if !event.pressed {
+				if swallowed[event.index] {
+					delete(swallowed, event.index)
+					continue
+				}
+				s.publish(event)
+				if releaseHandler == nil {
+					continue
+				}
+				s.dispatch(ctx, releaseHandler, event.index)
+				continue
+			}
+
+			// Ignore presses that follow the previous press of the same
+			// button too closely, along with their release, these are caused
+			// by a noisy button rather than a second physical press.
+			if s.debounce > 0 {
+				last, ok := lastPress[event.index]
+				lastPress[event.index] = event.at
+				if ok && event.at.Sub(last) < s.debounce {
+					swallowed[event.index] = true
+					continue
+				}
+			}
 
-				// TODO: we should probably do something about this error.
-				_ = s.SetSleeping(ctx, false)
+			if !s.wake(ctx) {
+				swallowed[event.index] = true
 				continue
 			}
 
+			s.publish(event)
+			s.notifyWaiters(event.index)
 			if pressHandler == nil {
 				continue
 			}
-			// TODO: we should probably do something about this error.
-			_ = pressHandler(ctx, index)
+			s.dispatch(ctx, pressHandler, event.index)
 		}
 	}
 }
+
+// WaitForPress blocks until the next button is pressed, returning the index of
+// the button that was pressed.
+//
+// Any handler set using StreamDeck#SetHandler will still be called for the
+// press. A press that wakes the Stream Deck from sleep will not be returned.
+func (s *StreamDeck) WaitForPress(ctx context.Context) (int, error) {
+	ch := make(chan int, 1)
+	s.waitersMx.Lock()
+	s.waiters = append(s.waiters, ch)
+	s.waitersMx.Unlock()
+
+	select {
+	case <-ctx.Done():
+		s.waitersMx.Lock()
+		for i, w := range s.waiters {
+			if w == ch {
+				s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+				break
+			}
+		}
+		s.waitersMx.Unlock()
+		return 0, ctx.Err()
+	case index := <-ch:
+		return index, nil
+	}
+}
+
+// Events returns a channel that receives an event whenever a button is pressed
+// or released, the channel is closed when the Stream Deck is closed.
+//
+// Events are delivered alongside any handlers set on the Stream Deck. Every
+// call returns a new channel, if a channel isn't read from fast enough events
+// sent to it will be dropped rather than blocking other consumers.
+func (s *StreamDeck) Events() <-chan ButtonEvent {
+	ch := make(chan ButtonEvent, eventBufferSize)
+
+	s.eventsMx.Lock()
+	defer s.eventsMx.Unlock()
+	if s.eventsClosed {
+		close(ch)
+		return ch
+	}
+	s.events = append(s.events, ch)
+	return ch
+}
+
+// publish sends an event to every channel returned by StreamDeck#Events.
+func (s *StreamDeck) publish(event buttonEvent) {
+	s.eventsMx.Lock()
+	defer s.eventsMx.Unlock()
+
+	e := ButtonEvent{Index: event.index, Pressed: event.pressed, Time: event.at, Chord: event.chord}
+	for _, ch := range s.events {
+		select {
+		case ch <- e:
+		default:
+			s.logger.Printf("streamdeck: dropped event for button %d, events channel is full\n", event.index)
+		}
+	}
+}
+
+// closeEvents closes every channel returned by StreamDeck#Events.
+func (s *StreamDeck) closeEvents() {
+	s.eventsMx.Lock()
+	defer s.eventsMx.Unlock()
+
+	for _, ch := range s.events {
+		close(ch)
+	}
+	s.events = nil
+	s.eventsClosed = true
+}
+
+// notifyWaiters notifies every caller of StreamDeck#WaitForPress that a button
+// was pressed.
+func (s *StreamDeck) notifyWaiters(index int) {
+	s.waitersMx.Lock()
+	waiters := s.waiters
+	s.waiters = nil
+	s.waitersMx.Unlock()
+
+	for _, ch := range waiters {
+		// Each channel is buffered and only ever receives a single value.
+		ch <- index
+	}
+}
+
+// wake wakes the Stream Deck if it is sleeping, returning false if the event
+// that woke it should be swallowed instead of being handled, see
+// WithWakeTriggersHandler.
+func (s *StreamDeck) wake(ctx context.Context) bool {
+	if !s.IsSleeping() {
+		return true
+	}
+	if err := s.SetSleeping(ctx, false); err != nil {
+		s.handleError(fmt.Errorf("streamdeck: failed to wake from sleep: %w", err))
+	}
+	return s.wakeTriggersHandler
+}
+
+// handleDial calls StreamDeck#dialHandler with a dial event.
+func (s *StreamDeck) handleDial(ctx context.Context, event DialEvent) {
+	s.dialHandlerMx.Lock()
+	dialHandler := s.dialHandler
+	s.dialHandlerMx.Unlock()
+	if dialHandler == nil {
+		return
+	}
+	if err := dialHandler(ctx, event); err != nil {
+		s.handleError(fmt.Errorf("streamdeck: handler for dial %d failed: %w", event.Index, err))
+	}
+}
+
+// dispatch calls a handler using the StreamDeck's EventPolicy.
+func (s *StreamDeck) dispatch(ctx context.Context, fn func(context.Context, int) error, index int) {
+	call := handlerCall{fn: fn, index: index}
+	switch s.eventPolicy {
+	case EventPolicyQueue:
+		select {
+		case <-ctx.Done():
+		case s.calls <- call:
+		}
+	case EventPolicyDrop:
+		select {
+		case s.calls <- call:
+		default:
+			s.logger.Printf("streamdeck: dropped event for button %d, handlers are busy\n", index)
+		}
+	default:
+		s.call(ctx, call)
+	}
+}
+
+// handlerWorker calls handlers sent over the StreamDeck#calls channel.
+func (s *StreamDeck) handlerWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case call := <-s.calls:
+			s.call(ctx, call)
+		}
+	}
+}
+
+// call calls a handler.
+func (s *StreamDeck) call(ctx context.Context, call handlerCall) {
+	if err := call.fn(ctx, call.index); err != nil {
+		s.handleError(fmt.Errorf("streamdeck: handler for button %d failed: %w", call.index, err))
+	}
+}
+
+// handleError passes an error to the error handler, or logs it if no error
+// handler is set.
+func (s *StreamDeck) handleError(err error) {
+	s.errorHandlerMx.Lock()
+	errorHandler := s.errorHandler
+	s.errorHandlerMx.Unlock()
+
+	if errorHandler == nil {
+		s.logger.Printf("%v\n", err)
+		return
+	}
+	errorHandler(err)
+}
@@ -0,0 +1,647 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package streamdeck_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/streamdeck"
+	"github.com/matthewpi/streamdeck/streamdecktest"
+)
+
+// eventually fails the test if cond doesn't return true within a second.
+func eventually(t *testing.T, cond func() bool, msg string) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// handled records the button events passed to the handlers of a Stream Deck.
+type handled struct {
+	mx     sync.Mutex
+	events []string
+}
+
+// recordHandlers sets the press and release handlers of a Stream Deck to
+// record every event they are called with.
+func recordHandlers(sd *streamdeck.StreamDeck) *handled {
+	h := &handled{}
+	record := func(kind string) func(context.Context, int) error {
+		return func(_ context.Context, index int) error {
+			h.mx.Lock()
+			h.events = append(h.events, fmt.Sprintf("%s %d", kind, index))
+			h.mx.Unlock()
+			return nil
+		}
+	}
+	sd.SetHandler(record("press"))
+	sd.SetReleaseHandler(record("release"))
+	return h
+}
+
+// get returns the events recorded so far.
+func (h *handled) get() []string {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	return append([]string(nil), h.events...)
+}
+
+// wait waits for the given events to be recorded, failing the test if any
+// other events are recorded.
+func (h *handled) wait(t *testing.T, want ...string) {
+	t.Helper()
+
+	eventually(t, func() bool { return len(h.get()) >= len(want) }, fmt.Sprintf("expected events %v, got %v", want, h.get()))
+	// Give any unexpected events a chance to arrive.
+	time.Sleep(10 * time.Millisecond)
+	if got := h.get(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected events %v, got %v", want, got)
+	}
+}
+
+// newStreamDeck returns a StreamDeck connected to tr, the StreamDeck is closed
+// when the test finishes.
+func newStreamDeck(t *testing.T, tr *streamdecktest.Transport, dt streamdeck.DeviceType, opts ...streamdeck.Option) *streamdeck.StreamDeck {
+	t.Helper()
+
+	d, err := streamdeck.NewDevice(dt, tr, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd, err := streamdeck.NewFromDevice(context.Background(), d, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = sd.Close(context.Background())
+	})
+	return sd
+}
+
+// dialTurnReport returns a Stream Deck Plus input report rotating the first
+// dial by one step.
+func dialTurnReport() []byte {
+	return []byte{0x01, 0x03, 0x05, 0x00, 0x01, 0x01, 0x00, 0x00, 0x00}
+}
+
+// TestDialResetsSleepTimeout checks that turning a dial keeps the Stream Deck
+// awake, the same as pressing a button.
+func TestDialResetsSleepTimeout(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+	sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productPlus), streamdeck.WithSleepTimeout(timeout))
+
+	// Keep turning a dial for several times the timeout.
+	for i := 0; i < 12; i++ {
+		tr.Input(dialTurnReport())
+		time.Sleep(timeout / 4)
+		if sd.IsSleeping() {
+			t.Fatal("expected the stream deck to stay awake while a dial is used")
+		}
+	}
+
+	eventually(t, sd.IsSleeping, "expected the stream deck to sleep once the dial is no longer used")
+}
+
+// TestDialWakes checks that a dial event wakes a sleeping Stream Deck without
+// being sent to the dial handler.
+func TestDialWakes(t *testing.T) {
+	sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productPlus))
+	events := make(chan streamdeck.DialEvent, 1)
+	sd.SetDialHandler(func(_ context.Context, event streamdeck.DialEvent) error {
+		events <- event
+		return nil
+	})
+
+	if err := sd.SetSleeping(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	tr.Input(dialTurnReport())
+	eventually(t, func() bool { return !sd.IsSleeping() }, "expected a dial event to wake the stream deck")
+
+	// The next dial event is sent to the handler.
+	tr.Input(dialTurnReport())
+	select {
+	case event := <-events:
+		if event.Kind != streamdeck.DialRotate || event.Index != 0 || event.Delta != 1 {
+			t.Errorf("unexpected dial event: %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the dial handler to be called")
+	}
+	select {
+	case event := <-events:
+		t.Errorf("the dial event that woke the stream deck was handled: %+v", event)
+	default:
+	}
+}
+
+// TestDebounce checks that a second press of a button within the debounce
+// duration is ignored along with its release.
+func TestDebounce(t *testing.T) {
+	const debounce = 200 * time.Millisecond
+	sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productOriginal), streamdeck.WithDebounce(debounce))
+	h := recordHandlers(sd)
+
+	// The noisy button bounces, the second press is ignored.
+	tr.Keys(0)
+	tr.Keys()
+	tr.Keys(0)
+	tr.Keys()
+	h.wait(t, "press 0", "release 0")
+
+	// Other buttons are debounced separately.
+	tr.Keys(1)
+	tr.Keys()
+	h.wait(t, "press 0", "release 0", "press 1", "release 1")
+
+	// Presses after the debounce duration are handled.
+	time.Sleep(debounce + 50*time.Millisecond)
+	tr.Keys(0)
+	tr.Keys()
+	h.wait(t, "press 0", "release 0", "press 1", "release 1", "press 0", "release 0")
+}
+
+// TestDebounceDisabled checks that presses aren't debounced by default.
+func TestDebounceDisabled(t *testing.T) {
+	sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productOriginal))
+	h := recordHandlers(sd)
+
+	tr.Keys(0)
+	tr.Keys()
+	tr.Keys(0)
+	tr.Keys()
+	h.wait(t, "press 0", "release 0", "press 0", "release 0")
+}
+
+// TestButtonReportDecode checks that button states are decoded from input
+// reports, including on Devices reporting an input packet size that is
+// smaller than a report.
+func TestButtonReportDecode(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		productID  uint16
+		packetSize int
+		// padding is the size the reports are padded to.
+		padding int
+	}{
+		{name: "original", productID: productOriginal},
+		{name: "original small packets", productID: productOriginal, packetSize: 8},
+		{name: "xl", productID: productXL, packetSize: 512, padding: 512},
+		{name: "xl small packets", productID: productXL, packetSize: 16},
+		{name: "plus small packets", productID: productPlus, packetSize: 1},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dt := streamdecktest.DeviceType(t, tc.productID)
+			tr := streamdecktest.NewTransport(dt)
+			tr.InputPacketSize = tc.packetSize
+			sd := newStreamDeck(t, tr, dt)
+			h := recordHandlers(sd)
+
+			report := func(pressed ...int) []byte {
+				r := tr.KeyReport(pressed...)
+				if len(r) < tc.padding {
+					r = append(r, make([]byte, tc.padding-len(r))...)
+				}
+				return r
+			}
+
+			last := dt.ButtonCount() - 1
+			tr.Input(report(0, 1, last))
+			tr.Input(report(1))
+			tr.Input(report())
+			h.wait(t,
+				"press 0", "press 1", fmt.Sprintf("press %d", last),
+				"release 0", fmt.Sprintf("release %d", last),
+				"release 1",
+			)
+		})
+	}
+}
+
+// TestSleepBrightness checks the brightness of the Device as the brightness
+// and sleep state of a Stream Deck are changed.
+func TestSleepBrightness(t *testing.T) {
+	for _, productID := range []uint16{productOriginal, productXL} {
+		productID := productID
+		t.Run(fmt.Sprintf("0x%02x", productID), func(t *testing.T) {
+			sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productID))
+			ctx := context.Background()
+
+			// check checks the brightness and sleep state of the Stream Deck,
+			// and the brightness of the Device.
+			check := func(step string, sleeping bool, brightness, device uint8) {
+				t.Helper()
+
+				if got := sd.IsSleeping(); got != sleeping {
+					t.Errorf("%s: expected sleeping to be %t", step, sleeping)
+				}
+				if got := sd.Brightness(); got != brightness {
+					t.Errorf("%s: expected a brightness of %d, got %d", step, brightness, got)
+				}
+				if got, ok := tr.Brightness(); !ok || got != device {
+					t.Errorf("%s: expected a device brightness of %d, got %d", step, device, got)
+				}
+			}
+
+			must := func(err error) {
+				t.Helper()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			must(sd.SetBrightness(ctx, 40))
+			check("set while awake", false, 40, 40)
+
+			must(sd.SetSleeping(ctx, true))
+			check("sleep", true, 40, streamdeck.BrightnessMin)
+			must(sd.SetBrightness(ctx, 70))
+			check("set while asleep", true, 70, streamdeck.BrightnessMin)
+			must(sd.SetSleeping(ctx, false))
+			check("wake", false, 70, 70)
+
+			sleeping, err := sd.ToggleSleep(ctx)
+			must(err)
+			if !sleeping {
+				t.Fatal("expected toggling to put the stream deck to sleep")
+			}
+			check("toggle to sleep", true, 70, streamdeck.BrightnessMin)
+			must(sd.SetBrightness(ctx, 20))
+			sleeping, err = sd.ToggleSleep(ctx)
+			must(err)
+			if sleeping {
+				t.Fatal("expected toggling to wake the stream deck")
+			}
+			check("toggle to wake", false, 20, 20)
+
+			// A press wakes the Stream Deck using the latest brightness.
+			must(sd.SetSleeping(ctx, true))
+			must(sd.SetBrightness(ctx, 55))
+			tr.Keys(0)
+			eventually(t, func() bool { return !sd.IsSleeping() }, "expected a press to wake the stream deck")
+			check("wake on press", false, 55, 55)
+		})
+	}
+}
+
+// TestSetHandlerInFlight checks that a press that was already dispatched runs
+// the previous handler to completion, while presses received afterwards use
+// the new handler.
+func TestSetHandlerInFlight(t *testing.T) {
+	sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productOriginal))
+
+	var (
+		started = make(chan int)
+		release = make(chan struct{})
+		calls   = make(chan string, 8)
+	)
+	sd.SetHandler(func(_ context.Context, index int) error {
+		started <- index
+		<-release
+		calls <- fmt.Sprintf("old %d", index)
+		return nil
+	})
+
+	tr.Keys(0)
+	tr.Keys()
+	<-started
+
+	// Swap the handler while the first press is still being handled.
+	sd.SetHandler(func(_ context.Context, index int) error {
+		calls <- fmt.Sprintf("new %d", index)
+		return nil
+	})
+	tr.Keys(1)
+	tr.Keys()
+	close(release)
+
+	for _, want := range []string{"old 0", "new 1"} {
+		select {
+		case got := <-calls:
+			if got != want {
+				t.Fatalf("expected %q, got %q", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected %q", want)
+		}
+	}
+
+	// A nil handler stops presses from being handled.
+	sd.SetHandler(nil)
+	tr.Keys(2)
+	tr.Keys()
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case got := <-calls:
+		t.Fatalf("expected no handler to be called, got %q", got)
+	default:
+	}
+}
+
+// TestSetHandlerConcurrent swaps the handlers while presses are being
+// received, checking that nothing panics and that every press is handled by
+// at most one handler. Run with -race to also check for data races.
+func TestSetHandlerConcurrent(t *testing.T) {
+	for _, policy := range []streamdeck.EventPolicy{streamdeck.EventPolicyBlock, streamdeck.EventPolicyQueue} {
+		policy := policy
+		t.Run(fmt.Sprintf("policy %d", policy), func(t *testing.T) {
+			sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productOriginal), streamdeck.WithEventPolicy(policy))
+
+			var (
+				mx    sync.Mutex
+				calls = make(map[string]int)
+			)
+			handler := func(name string) func(context.Context, int) error {
+				return func(_ context.Context, index int) error {
+					mx.Lock()
+					calls[fmt.Sprintf("%s %d", name, index)]++
+					mx.Unlock()
+					return nil
+				}
+			}
+			handlers := []func(context.Context, int) error{handler("a"), handler("b"), nil}
+
+			const presses = 200
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 0; i < presses; i++ {
+					tr.Keys(i % 4)
+					tr.Keys()
+				}
+			}()
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+				default:
+					sd.SetHandler(handlers[i%len(handlers)])
+					sd.SetReleaseHandler(handlers[(i+1)%len(handlers)])
+					continue
+				}
+				break
+			}
+
+			// Once the handler is no longer being swapped, every press uses
+			// the current handler.
+			sd.SetHandler(handler("final"))
+			tr.Keys(5)
+			tr.Keys()
+			eventually(t, func() bool {
+				mx.Lock()
+				defer mx.Unlock()
+				return calls["final 5"] == 1
+			}, "expected the final handler to be called")
+
+			mx.Lock()
+			defer mx.Unlock()
+			var total int
+			for _, n := range calls {
+				total += n
+			}
+			// Every press and release is handled at most once.
+			if total > 2*(presses+1) {
+				t.Errorf("expected at most %d calls, got %d", 2*(presses+1), total)
+			}
+		})
+	}
+}
+
+// TestWakeTriggersHandler checks that a press that wakes the Stream Deck is
+// swallowed along with its release, unless WithWakeTriggersHandler is used.
+func TestWakeTriggersHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []streamdeck.Option
+		want []string
+	}{
+		{
+			name: "default",
+			want: []string{"press 1", "release 1"},
+		},
+		{
+			name: "triggers handler",
+			opts: []streamdeck.Option{streamdeck.WithWakeTriggersHandler()},
+			want: []string{"press 0", "release 0", "press 1", "release 1"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productOriginal), tt.opts...)
+			h := recordHandlers(sd)
+
+			if err := sd.SetSleeping(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+
+			tr.Keys(0)
+			eventually(t, func() bool { return !sd.IsSleeping() }, "expected a press to wake the stream deck")
+			tr.Keys()
+			tr.Keys(1)
+			tr.Keys()
+			h.wait(t, tt.want...)
+		})
+	}
+}
+
+// TestSleepBrightnessConcurrent randomly interleaves brightness and sleep
+// changes across goroutines, checking that the brightness of the Device always
+// ends up matching the state of the Stream Deck.
+func TestSleepBrightnessConcurrent(t *testing.T) {
+	seed := time.Now().UnixNano()
+	t.Logf("seed %d", seed)
+
+	for _, productID := range []uint16{productOriginal, productXL} {
+		productID := productID
+		t.Run(fmt.Sprintf("0x%02x", productID), func(t *testing.T) {
+			dt := streamdecktest.DeviceType(t, productID)
+			tr := streamdecktest.NewTransport(dt)
+			// Yield while sending each report, widening the window for a
+			// brightness change to race a sleep change.
+			tr.OnFeatureReport = func([]byte) error {
+				time.Sleep(50 * time.Microsecond)
+				return nil
+			}
+			sd := newStreamDeck(t, tr, dt)
+			ctx := context.Background()
+
+			// Check the state after each burst of changes, as only the last
+			// changes in a burst can leave the Device out of sync.
+			for round := 0; round < 10; round++ {
+				var wg sync.WaitGroup
+				for g := 0; g < 8; g++ {
+					r := rand.New(rand.NewSource(seed + int64(round*8+g)))
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+
+						for i := 0; i < 5; i++ {
+							var err error
+							switch r.Intn(3) {
+							case 0:
+								err = sd.SetBrightness(ctx, uint8(r.Intn(int(streamdeck.BrightnessFull)+1)))
+							case 1:
+								err = sd.SetSleeping(ctx, r.Intn(2) == 0)
+							case 2:
+								_, err = sd.ToggleSleep(ctx)
+							}
+							if err != nil {
+								t.Error(err)
+								return
+							}
+						}
+					}()
+				}
+				wg.Wait()
+
+				want := sd.Brightness()
+				if sd.IsSleeping() {
+					want = streamdeck.BrightnessMin
+				}
+				if got, ok := tr.Brightness(); !ok || got != want {
+					t.Fatalf("round %d: expected a device brightness of %d (sleeping: %t), got %d", round, want, sd.IsSleeping(), got)
+				}
+			}
+		})
+	}
+}
+
+// handlerView is a View that records the button events it handles.
+type handlerView struct {
+	h handled
+}
+
+func (v *handlerView) Apply(context.Context) error { return nil }
+
+func (v *handlerView) Handle(_ context.Context, index int) error {
+	v.h.mx.Lock()
+	v.h.events = append(v.h.events, fmt.Sprintf("press %d", index))
+	v.h.mx.Unlock()
+	return nil
+}
+
+func (v *handlerView) HandleRelease(_ context.Context, index int) error {
+	v.h.mx.Lock()
+	v.h.events = append(v.h.events, fmt.Sprintf("release %d", index))
+	v.h.mx.Unlock()
+	return nil
+}
+
+// staticView is a View that doesn't handle any button events.
+type staticView struct{}
+
+func (staticView) Apply(context.Context) error { return nil }
+
+// TestSetViewClearsHandlers checks that the handlers of the previous View are
+// cleared when switching to a View that doesn't handle button events.
+func TestSetViewClearsHandlers(t *testing.T) {
+	sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productOriginal))
+	ctx := context.Background()
+
+	v := &handlerView{}
+	if err := sd.SetView(ctx, v); err != nil {
+		t.Fatal(err)
+	}
+	tr.Keys(0)
+	tr.Keys()
+	v.h.wait(t, "press 0", "release 0")
+
+	if err := sd.SetView(ctx, staticView{}); err != nil {
+		t.Fatal(err)
+	}
+	tr.Keys(1)
+	tr.Keys()
+	v.h.wait(t, "press 0", "release 0")
+}
+
+// dialPushReport returns a Stream Deck Plus input report with the first dial
+// pressed or released.
+func dialPushReport(pressed bool) []byte {
+	report := []byte{0x01, 0x03, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	if pressed {
+		report[5] = 0x01
+	}
+	return report
+}
+
+// TestDialWakeTriggersHandler checks that a dial event that wakes the Stream
+// Deck is handled the same as a button press, swallowed along with the release
+// of the dial unless WithWakeTriggersHandler is used.
+func TestDialWakeTriggersHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []streamdeck.Option
+		want []string
+	}{
+		{
+			name: "default",
+			want: []string{"rotate 0"},
+		},
+		{
+			name: "triggers handler",
+			opts: []streamdeck.Option{streamdeck.WithWakeTriggersHandler()},
+			want: []string{"press 0", "release 0", "rotate 0"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productPlus), tt.opts...)
+			h := &handled{}
+			sd.SetDialHandler(func(_ context.Context, event streamdeck.DialEvent) error {
+				kind := map[streamdeck.DialEventKind]string{
+					streamdeck.DialRotate:  "rotate",
+					streamdeck.DialPress:   "press",
+					streamdeck.DialRelease: "release",
+				}[event.Kind]
+				h.mx.Lock()
+				h.events = append(h.events, fmt.Sprintf("%s %d", kind, event.Index))
+				h.mx.Unlock()
+				return nil
+			})
+
+			if err := sd.SetSleeping(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+
+			tr.Input(dialPushReport(true))
+			eventually(t, func() bool { return !sd.IsSleeping() }, "expected pressing a dial to wake the stream deck")
+			tr.Input(dialPushReport(false))
+			tr.Input(dialTurnReport())
+			h.wait(t, tt.want...)
+		})
+	}
+}
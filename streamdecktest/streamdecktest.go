@@ -0,0 +1,455 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+// Package streamdecktest provides a fake Transport used to test code using a
+// Stream Deck without any hardware.
+package streamdecktest
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/streamdeck"
+)
+
+// Transport is a fake streamdeck.Transport that records everything written to
+// it and reads input reports queued by the test.
+//
+// Images written to the Transport are reassembled, so the image displayed by
+// each button can be checked using Transport#Image.
+type Transport struct {
+	// OnWrite is called with every output report before it is recorded, the
+	// write fails if it returns an error. It must be set before the
+	// Transport is used.
+	OnWrite func(v []byte) error
+	// OnFeatureReport is called with every feature report before it is
+	// recorded, sending the report fails if it returns an error. It must be
+	// set before the Transport is used.
+	OnFeatureReport func(v []byte) error
+	// OnImage is called whenever the last packet of an image is written to a
+	// button. It must be set before the Transport is used.
+	OnImage func(button int, image []byte)
+	// OnClose is called whenever the Transport is closed, its error is
+	// returned by Close. It must be set before the Transport is used.
+	OnClose func() error
+	// InputPacketSize is the maximum packet size reported for the input
+	// endpoint, 512 is used if it is zero. Reading an input report larger
+	// than the buffer passed to Read fails, like it does for a real Device.
+	// It must be set before the Transport is used.
+	InputPacketSize int
+
+	dt streamdeck.DeviceType
+
+	mx             sync.Mutex
+	writes         [][]byte
+	featureReports [][]byte
+	responses      map[byte][]byte
+	initialState   []byte
+	pending        map[int][]byte
+	images         map[int][]byte
+	imageCounts    map[int]int
+	closeCount     int
+	closed         bool
+	interleaved    int
+
+	input chan []byte
+	done  chan struct{}
+}
+
+var _ streamdeck.Transport = (*Transport)(nil)
+
+// NewTransport returns a Transport for a Device of the given type.
+func NewTransport(dt streamdeck.DeviceType) *Transport {
+	return &Transport{
+		dt: dt,
+
+		responses:   make(map[byte][]byte),
+		pending:     make(map[int][]byte),
+		images:      make(map[int][]byte),
+		imageCounts: make(map[int]int),
+
+		input: make(chan []byte, 64),
+		done:  make(chan struct{}),
+	}
+}
+
+// NewDevice returns a Device of the given type connected to a new Transport,
+// the Device is closed when the test finishes.
+func NewDevice(tb testing.TB, dt streamdeck.DeviceType, opts ...streamdeck.Option) (*streamdeck.Device, *Transport) {
+	tb.Helper()
+
+	t := NewTransport(dt)
+	d, err := streamdeck.NewDevice(dt, t, opts...)
+	if err != nil {
+		tb.Fatalf("streamdecktest: failed to create device: %v", err)
+	}
+	tb.Cleanup(func() {
+		_ = d.Close(context.Background())
+	})
+	return d, t
+}
+
+// NewStreamDeck returns a StreamDeck of the given type connected to a new
+// Transport, the StreamDeck is closed when the test finishes.
+func NewStreamDeck(tb testing.TB, dt streamdeck.DeviceType, opts ...streamdeck.Option) (*streamdeck.StreamDeck, *Transport) {
+	tb.Helper()
+
+	d, t := NewDevice(tb, dt, opts...)
+	sd, err := streamdeck.NewFromDevice(context.Background(), d, opts...)
+	if err != nil {
+		tb.Fatalf("streamdecktest: failed to create streamdeck: %v", err)
+	}
+	tb.Cleanup(func() {
+		_ = sd.Close(context.Background())
+	})
+	return sd, t
+}
+
+// DeviceType returns the registered DeviceType with the given product id,
+// failing the test if there isn't one.
+func DeviceType(tb testing.TB, productID uint16) streamdeck.DeviceType {
+	tb.Helper()
+
+	dt, ok := streamdeck.DeviceTypeByProductID(productID)
+	if !ok {
+		tb.Fatalf("streamdecktest: no device type with product id 0x%04x", productID)
+	}
+	return dt
+}
+
+// Input queues an input report to be read from the Transport.
+func (t *Transport) Input(report []byte) {
+	select {
+	case <-t.done:
+	case t.input <- bytes.Clone(report):
+	}
+}
+
+// Keys queues an input report with the given buttons held down, every other
+// button is released.
+func (t *Transport) Keys(pressed ...int) {
+	t.Input(t.KeyReport(pressed...))
+}
+
+// KeyReport returns an input report with the given buttons held down.
+func (t *Transport) KeyReport(pressed ...int) []byte {
+	count := t.dt.ButtonCount()
+	report := make([]byte, t.dt.ButtonOffset+count)
+	report[0] = 0x01
+	if t.dt.ButtonOffset == 4 {
+		binary.LittleEndian.PutUint16(report[2:4], uint16(count))
+	}
+	for _, i := range pressed {
+		report[t.dt.ButtonOffset+i] = 0x01
+	}
+	return report
+}
+
+// SetInitialState sets the report returned when the state of every button is
+// requested from the Transport, see streamdeck.WithInitialState.
+func (t *Transport) SetInitialState(report []byte) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.initialState = bytes.Clone(report)
+}
+
+// SetFeatureReport sets the report returned when the feature report with the
+// id in the first byte of the report is read from the Transport.
+func (t *Transport) SetFeatureReport(report []byte) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.responses[report[0]] = bytes.Clone(report)
+}
+
+// Writes returns every output report written to the Transport.
+func (t *Transport) Writes() [][]byte {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	return append([][]byte(nil), t.writes...)
+}
+
+// FeatureReports returns every feature report sent to the Transport.
+func (t *Transport) FeatureReports() [][]byte {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	return append([][]byte(nil), t.featureReports...)
+}
+
+// Image returns the last image written to a button, nil if no image has been
+// written to the button.
+//
+// Images sent to the original Stream Deck and the Stream Deck Mini are padded
+// with zeros to the size of a packet.
+func (t *Transport) Image(button int) []byte {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	return t.images[button]
+}
+
+// ImageCount returns the number of images written to a button.
+func (t *Transport) ImageCount(button int) int {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	return t.imageCounts[button]
+}
+
+// Brightness returns the brightness set by the last brightness feature report
+// sent to the Transport, ok is false if the brightness was never set.
+func (t *Transport) Brightness() (brightness uint8, ok bool) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	for i := len(t.featureReports) - 1; i >= 0; i-- {
+		v := t.featureReports[i]
+		switch {
+		case t.dt.ButtonOffset == 4 && len(v) > 2 && v[0] == 0x03 && v[1] == 0x08:
+			return v[2], true
+		case t.dt.ButtonOffset == 1 && len(v) > 5 && bytes.Equal(v[:5], []byte{0x05, 0x55, 0xaa, 0xd1, 0x01}):
+			return v[5], true
+		}
+	}
+	return 0, false
+}
+
+// Interleaved returns the number of image packets that were written while an
+// image for another button was only partially written, this is always zero
+// unless writes to the Device are not serialized.
+func (t *Transport) Interleaved() int {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	return t.interleaved
+}
+
+// CloseCount returns the number of times the Transport was closed.
+func (t *Transport) CloseCount() int {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	return t.closeCount
+}
+
+// Read satisfies the streamdeck.Transport interface.
+func (t *Transport) Read(ctx context.Context, v []byte, timeout time.Duration) (int, error) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-t.done:
+		return 0, streamdeck.ErrDisconnected
+	case <-expired:
+		return 0, streamdeck.ErrTimeout
+	case report := <-t.input:
+		if len(report) > len(v) {
+			return 0, fmt.Errorf("streamdecktest: input report of %d bytes overflows the %d byte buffer", len(report), len(v))
+		}
+		return copy(v, report), nil
+	}
+}
+
+// Write satisfies the streamdeck.Transport interface.
+func (t *Transport) Write(_ context.Context, v []byte) (int, error) {
+	if err := t.checkClosed(); err != nil {
+		return 0, err
+	}
+	if t.OnWrite != nil {
+		if err := t.OnWrite(v); err != nil {
+			return 0, err
+		}
+	}
+
+	t.mx.Lock()
+	t.writes = append(t.writes, bytes.Clone(v))
+	button, image, ok := t.record(v)
+	t.mx.Unlock()
+
+	if ok && t.OnImage != nil {
+		t.OnImage(button, image)
+	}
+	return len(v), nil
+}
+
+// record reassembles the packets of an image, returning the image once its last
+// packet has been written. t.mx must be held.
+func (t *Transport) record(v []byte) (button int, image []byte, ok bool) {
+	var (
+		data []byte
+		last bool
+	)
+	switch {
+	case t.dt.ButtonOffset == 1 && len(v) > 16 && v[0] == 0x02 && v[1] == 0x01:
+		// Original Stream Deck and Stream Deck Mini.
+		button, last, data = int(v[5])-1, v[4] == 0x01, v[16:]
+		if v[2] == 0 {
+			delete(t.pending, button)
+		}
+	case t.dt.ButtonOffset == 4 && len(v) > 8 && v[0] == 0x02 && v[1] == 0x07:
+		size := int(binary.LittleEndian.Uint16(v[4:6]))
+		if size > len(v)-8 {
+			return 0, nil, false
+		}
+		button, last, data = int(v[2]), v[3] == 0x01, v[8:8+size]
+		if binary.LittleEndian.Uint16(v[6:8]) == 0 {
+			delete(t.pending, button)
+		}
+	default:
+		return 0, nil, false
+	}
+
+	// A Device can't tell which image a packet belongs to, so the packets of
+	// an image must never be interleaved with the packets of another image.
+	for pending := range t.pending {
+		if pending != button {
+			t.interleaved++
+			break
+		}
+	}
+
+	t.pending[button] = append(t.pending[button], data...)
+	if !last {
+		return 0, nil, false
+	}
+	image = t.pending[button]
+	delete(t.pending, button)
+	t.images[button] = image
+	t.imageCounts[button]++
+	return button, image, true
+}
+
+// GetFeatureReport satisfies the streamdeck.Transport interface.
+func (t *Transport) GetFeatureReport(_ context.Context, v []byte) (int, error) {
+	if err := t.checkClosed(); err != nil {
+		return 0, err
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	return copy(v, t.responses[v[0]]), nil
+}
+
+// GetInputReport satisfies the streamdeck.Transport interface.
+func (t *Transport) GetInputReport(_ context.Context, v []byte) (int, error) {
+	if err := t.checkClosed(); err != nil {
+		return 0, err
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	if t.initialState == nil {
+		return 0, streamdeck.ErrTimeout
+	}
+	return copy(v, t.initialState), nil
+}
+
+// SendFeatureReport satisfies the streamdeck.Transport interface.
+func (t *Transport) SendFeatureReport(_ context.Context, v []byte) (int, error) {
+	if err := t.checkClosed(); err != nil {
+		return 0, err
+	}
+	if t.OnFeatureReport != nil {
+		if err := t.OnFeatureReport(v); err != nil {
+			return 0, err
+		}
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.featureReports = append(t.featureReports, bytes.Clone(v))
+	return len(v), nil
+}
+
+// ReportDescriptor satisfies the streamdeck.Transport interface.
+func (t *Transport) ReportDescriptor(context.Context) ([]byte, error) {
+	if err := t.checkClosed(); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// USBInfo satisfies the streamdeck.Transport interface.
+func (t *Transport) USBInfo() streamdeck.USBInfo {
+	return streamdeck.USBInfo{
+		VendorID:  0x0fd9,
+		ProductID: t.dt.ProductID,
+
+		EndpointIn:  0x81,
+		EndpointOut: 0x02,
+
+		InputPacketSize:  t.inputPacketSize(),
+		OutputPacketSize: 1024,
+	}
+}
+
+// inputPacketSize returns the maximum packet size of the input endpoint.
+func (t *Transport) inputPacketSize() int {
+	if t.InputPacketSize == 0 {
+		return 512
+	}
+	return t.InputPacketSize
+}
+
+// Close satisfies the streamdeck.Transport interface.
+//
+// Unlike a real connection, closing the Transport more than once is not an
+// error so the number of calls can be checked using Transport#CloseCount.
+func (t *Transport) Close(context.Context) error {
+	t.mx.Lock()
+	t.closeCount++
+	if !t.closed {
+		t.closed = true
+		close(t.done)
+	}
+	t.mx.Unlock()
+
+	if t.OnClose != nil {
+		return t.OnClose()
+	}
+	return nil
+}
+
+// checkClosed returns streamdeck.ErrDisconnected if the Transport was closed.
+func (t *Transport) checkClosed() error {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if t.closed {
+		return streamdeck.ErrDisconnected
+	}
+	return nil
+}
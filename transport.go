@@ -0,0 +1,122 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package streamdeck
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/matthewpi/streamdeck/internal/hid"
+)
+
+// Transport is the connection used to communicate with a Device.
+//
+// Devices opened using Open are connected using USB HID, a custom Transport may
+// be used with NewDevice, for example to test code using a Device without any
+// hardware, see the streamdecktest package.
+type Transport interface {
+	// Read reads an input report from the Device, blocking until a report is
+	// received or the timeout passes. A timeout of zero waits until the
+	// context is done. ErrTimeout is returned if the timeout passes.
+	Read(ctx context.Context, v []byte, timeout time.Duration) (int, error)
+	// Write writes an output report to the Device.
+	Write(ctx context.Context, v []byte) (int, error)
+	// GetFeatureReport reads the feature report with the id in the first byte
+	// of v into v.
+	GetFeatureReport(ctx context.Context, v []byte) (int, error)
+	// GetInputReport reads the input report with the id in the first byte of
+	// v into v.
+	GetInputReport(ctx context.Context, v []byte) (int, error)
+	// SendFeatureReport sends a feature report to the Device.
+	SendFeatureReport(ctx context.Context, v []byte) (int, error)
+	// ReportDescriptor reads the raw HID report descriptor of the Device.
+	ReportDescriptor(ctx context.Context) ([]byte, error)
+	// USBInfo returns information about the USB connection to the Device.
+	USBInfo() USBInfo
+	// Close closes the connection to the Device.
+	Close(ctx context.Context) error
+}
+
+// usbTransport is a Transport connected using USB HID.
+type usbTransport struct {
+	*hid.USB
+}
+
+var _ Transport = usbTransport{}
+
+// USBInfo satisfies the Transport interface.
+func (t usbTransport) USBInfo() USBInfo {
+	info := t.Info()
+	return USBInfo{
+		VendorID:  info.VendorID,
+		ProductID: info.ProductID,
+		Revision:  info.Revision,
+
+		Interface: info.Interface,
+		Bus:       info.Bus,
+		Device:    info.Device,
+
+		EndpointIn:  t.EndpointIn(),
+		EndpointOut: t.EndpointOut(),
+
+		InputPacketSize:  t.InputPacketSize(),
+		OutputPacketSize: t.OutputPacketSize(),
+	}
+}
+
+// NewDevice returns a Device of the given type connected using a Transport,
+// most users should use Open instead.
+//
+// Unlike Open, the Device is not reset. The Transport is closed when the
+// Device is closed.
+func NewDevice(dt DeviceType, t Transport, opts ...Option) (*Device, error) {
+	if t == nil {
+		return nil, errors.New("streamdeck: transport cannot be nil")
+	}
+	if err := dt.Validate(); err != nil {
+		return nil, err
+	}
+	return newDevice(dt, t, newOptions(opts))
+}
+
+// newDevice returns a Device of the given type connected using a Transport.
+func newDevice(dt DeviceType, t Transport, o options) (*Device, error) {
+	// Get a blank image to use when a button has no image set.
+	var blank []byte
+	if dt.HasDisplay() {
+		v, err := blankImage(dt.ImageFormat, dt.ImageDimensions())
+		if err != nil {
+			return nil, err
+		}
+		blank = v
+	}
+	return &Device{
+		DeviceType: dt,
+
+		fd:           t,
+		blankImage:   blank,
+		resetOnClose: o.resetOnClose,
+		metrics:      o.metrics,
+	}, nil
+}
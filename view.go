@@ -28,7 +28,36 @@ import (
 
 // View represents a view capable of updating the images displayed on a
 // StreamDeck.
+//
+// Views should be set using StreamDeck#SetView, which cancels the context
+// passed to the previous View's Apply method. Any goroutines started by a View,
+// like those used to animate buttons, must exit once that context is
+// cancelled.
 type View interface {
 	// Apply applies the View to a StreamDeck.
+	//
+	// The context passed to Apply remains valid for as long as the View is
+	// displayed when set using StreamDeck#SetView.
 	Apply(context.Context) error
 }
+
+// PressHandler is an optional interface a View may implement to handle button
+// presses while it is displayed.
+type PressHandler interface {
+	// Handle is called whenever a button is pressed.
+	Handle(context.Context, int) error
+}
+
+// ReleaseHandler is an optional interface a View may implement to handle button
+// releases while it is displayed.
+type ReleaseHandler interface {
+	// HandleRelease is called whenever a button is released.
+	HandleRelease(context.Context, int) error
+}
+
+// ResetHandler is an optional interface a View may implement to be notified
+// when the StreamDeck is reset, allowing it to display its content again.
+type ResetHandler interface {
+	// OnReset is called after the StreamDeck has been reset.
+	OnReset(context.Context) error
+}
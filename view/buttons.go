@@ -25,7 +25,6 @@ package view
 import (
 	"context"
 	"errors"
-	"log"
 	"sync"
 
 	"github.com/matthewpi/streamdeck"
@@ -39,9 +38,26 @@ type Buttons struct {
 
 	buttonsMx sync.Mutex
 	buttons   []button.Button
+	handler   func(context.Context, int) error
+
+	// owned contains the indexes of the buttons managed by the view, if nil
+	// the view manages every button on the Stream Deck.
+	owned map[int]bool
+
+	// animationsMx is a mutex used to protect the animations field, it is also
+	// held while an animation writes a frame.
+	animationsMx sync.Mutex
+	// animations contains the cancel function for the animation running on
+	// each button, if any.
+	animations []context.CancelFunc
 }
 
-var _ streamdeck.View = (*Buttons)(nil)
+var (
+	_ streamdeck.View           = (*Buttons)(nil)
+	_ streamdeck.PressHandler   = (*Buttons)(nil)
+	_ streamdeck.ReleaseHandler = (*Buttons)(nil)
+	_ streamdeck.ResetHandler   = (*Buttons)(nil)
+)
 
 // NewButtons returns a Buttons View capable of displaying multiple static
 // and/or animated buttons.
@@ -49,64 +65,268 @@ func NewButtons(sd *streamdeck.StreamDeck) (*Buttons, error) {
 	if sd == nil {
 		return nil, errors.New("view: streamdeck cannot be nil")
 	}
+	count := sd.Device().ButtonCount()
 	return &Buttons{
-		sd:      sd,
-		buttons: make([]button.Button, sd.Device().ButtonCount()),
+		sd:         sd,
+		buttons:    make([]button.Button, count),
+		animations: make([]context.CancelFunc, count),
 	}, nil
 }
 
+// NewButtonsSubset returns a Buttons View that only manages the buttons at the
+// given indexes, any other buttons are never updated by the view and presses
+// on them are ignored.
+//
+// This allows multiple views to share a Stream Deck without overwriting each
+// other's buttons, see Composite.
+func NewButtonsSubset(sd *streamdeck.StreamDeck, indexes []int) (*Buttons, error) {
+	b, err := NewButtons(sd)
+	if err != nil {
+		return nil, err
+	}
+	b.owned = make(map[int]bool, len(indexes))
+	for _, i := range indexes {
+		if i < 0 || i >= len(b.buttons) {
+			return nil, errors.New("view: button out of range")
+		}
+		b.owned[i] = true
+	}
+	return b, nil
+}
+
+// NewButtonsRange returns a Buttons View that only manages the buttons with an
+// index in the range [start, end), see NewButtonsSubset.
+func NewButtonsRange(sd *streamdeck.StreamDeck, start, end int) (*Buttons, error) {
+	if start > end {
+		return nil, errors.New("view: invalid button range")
+	}
+	indexes := make([]int, 0, end-start)
+	for i := start; i < end; i++ {
+		indexes = append(indexes, i)
+	}
+	return NewButtonsSubset(sd, indexes)
+}
+
+// Owns returns true if the button at index is managed by the view.
+func (b *Buttons) Owns(index int) bool {
+	if index < 0 || index >= len(b.buttons) {
+		return false
+	}
+	return b.owned == nil || b.owned[index]
+}
+
 // Apply updates the displayed content for all buttons on the Stream Deck.
+//
+// If a handler was set using Buttons#SetHandler or the view contains any
+// button.Pressable buttons, Buttons#Handle will be set as the Stream Deck's
+// button press handler. If the view contains any button.Releasable buttons,
+// Buttons#HandleRelease will be set as the button release handler. Otherwise
+// the handlers are cleared, so presses no longer reach the previous view.
 func (b *Buttons) Apply(ctx context.Context) error {
+	if err := b.apply(ctx, nil); err != nil {
+		return err
+	}
+
+	b.buttonsMx.Lock()
+	handle := b.handler != nil
+	var handleRelease bool
+	for _, btn := range b.buttons {
+		if _, ok := btn.(button.Pressable); ok {
+			handle = true
+		}
+		if _, ok := btn.(button.Releasable); ok {
+			handleRelease = true
+		}
+	}
+	b.buttonsMx.Unlock()
+	if handle {
+		b.sd.SetHandler(b.Handle)
+	} else {
+		b.sd.SetHandler(nil)
+	}
+	if handleRelease {
+		b.sd.SetReleaseHandler(b.HandleRelease)
+	} else {
+		b.sd.SetReleaseHandler(nil)
+	}
+	return nil
+}
+
+// SetHandler sets the button press handler used when the view is applied.
+//
+// This method is safe to call concurrently.
+func (b *Buttons) SetHandler(fn func(context.Context, int) error) *Buttons {
+	b.buttonsMx.Lock()
+	b.handler = fn
+	b.buttonsMx.Unlock()
+	return b
+}
+
+// Handle handles a button press, calling OnPress if the button is a
+// button.Pressable and the button press handler set on the view, if any.
+func (b *Buttons) Handle(ctx context.Context, index int) error {
+	if !b.Owns(index) {
+		return nil
+	}
+
+	b.buttonsMx.Lock()
+	handler := b.handler
+	var btn button.Button
+	if index >= 0 && index < len(b.buttons) {
+		btn = b.buttons[index]
+	}
+	b.buttonsMx.Unlock()
+
+	if p, ok := btn.(button.Pressable); ok {
+		if err := p.OnPress(ctx); err != nil {
+			return err
+		}
+		// Animated buttons are responsible for updating themselves.
+		if _, ok := btn.(button.Animated); !ok {
+			if err := b.updateButton(ctx, index, btn); err != nil {
+				return err
+			}
+		}
+	}
+
+	if handler == nil {
+		return nil
+	}
+	return handler(ctx, index)
+}
+
+// HandleRelease handles a button release, calling OnRelease if the button is a
+// button.Releasable and updating the button's image afterwards.
+func (b *Buttons) HandleRelease(ctx context.Context, index int) error {
+	if !b.Owns(index) {
+		return nil
+	}
+
+	b.buttonsMx.Lock()
+	btn := b.buttons[index]
+	b.buttonsMx.Unlock()
+
+	r, ok := btn.(button.Releasable)
+	if !ok {
+		return nil
+	}
+	if err := r.OnRelease(ctx); err != nil {
+		return err
+	}
+	// Animated buttons are responsible for updating themselves.
+	if _, ok := btn.(button.Animated); ok {
+		return nil
+	}
+	return b.updateButton(ctx, index, btn)
+}
+
+// apply updates the displayed content for all buttons managed by the view,
+// skipping any buttons where skip returns true.
+func (b *Buttons) apply(ctx context.Context, skip func(int) bool) error {
 	b.buttonsMx.Lock()
 	defer b.buttonsMx.Unlock()
 
 	for i, btn := range b.buttons {
-		if btn, ok := btn.(button.Animated); ok {
-			i := i
-			btn := btn
-			go b.animate(ctx, i, btn)
+		if !b.Owns(i) || (skip != nil && skip(i)) {
 			continue
 		}
 
-		if err := b.updateButton(ctx, i, btn); err != nil {
+		if err := b.show(ctx, i, btn); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// OnReset displays all buttons again after the Stream Deck has been reset.
+func (b *Buttons) OnReset(ctx context.Context) error {
+	return b.apply(ctx, nil)
+}
+
+// Close stops all animations started by the view.
+func (b *Buttons) Close() {
+	for i := range b.animations {
+		b.stop(i)
+	}
+}
+
 // Set sets a Button on the view, it will not render the image on a
 // Stream Deck, a separate call to View#Apply or Buttons#Update is required to
 // actually apply the change(s).
 //
+// If the Button being replaced is animated, its animation will be stopped. A
+// Button set at an index that isn't managed by the view is never displayed.
+//
 // This method is safe to call concurrently.
 func (b *Buttons) Set(index int, btn button.Button) *Buttons {
 	b.buttonsMx.Lock()
 	b.buttons[index] = btn
 	b.buttonsMx.Unlock()
+	b.stop(index)
 	return b
 }
 
 // Update updates the image displayed on a StreamDeck using the Button set on
 // this view.
 func (b *Buttons) Update(ctx context.Context, index int) error {
-	if index >= len(b.buttons) {
+	if index < 0 || index >= len(b.buttons) {
 		return errors.New("view: button out of range")
 	}
+	if !b.Owns(index) {
+		return errors.New("view: button is not managed by the view")
+	}
 
 	b.buttonsMx.Lock()
 	btn := b.buttons[index]
 	b.buttonsMx.Unlock()
+	return b.show(ctx, index, btn)
+}
+
+// show displays a Button, stopping any animation that is already running on
+// the button and starting a new one if the Button is animated.
+//
+// Stopping the running animation first ensures only one animation is ever
+// running per button, even if the view is applied multiple times.
+func (b *Buttons) show(ctx context.Context, index int, btn button.Button) error {
+	b.stop(index)
+
+	if btn, ok := btn.(button.Animated); ok {
+		ctx, cancel := context.WithCancel(ctx)
+		b.animationsMx.Lock()
+		b.animations[index] = cancel
+		b.animationsMx.Unlock()
+		go b.animate(ctx, index, btn)
+		return nil
+	}
 	return b.updateButton(ctx, index, btn)
 }
 
+// stop stops the animation running on a button, if any.
+func (b *Buttons) stop(index int) {
+	b.animationsMx.Lock()
+	defer b.animationsMx.Unlock()
+
+	if cancel := b.animations[index]; cancel != nil {
+		cancel()
+		b.animations[index] = nil
+	}
+}
+
 func (b *Buttons) animate(ctx context.Context, i int, btn button.Animated) {
 	fn := func(ctx context.Context, v []byte) error {
+		// Hold the animations mutex while writing the frame and check if the
+		// animation was stopped, this ensures a frame is never written after
+		// the animation has been stopped and the button was replaced.
+		b.animationsMx.Lock()
+		defer b.animationsMx.Unlock()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		return b.update(ctx, i, v)
 	}
 
 	if err := btn.Animate(ctx, fn); err != nil && !errors.Is(err, context.Canceled) {
-		log.Printf("failed to animate button: %v\n", err)
+		b.sd.Logger().Printf("view: failed to animate button %d: %v\n", i, err)
 	}
 }
 
@@ -0,0 +1,347 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package view_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/streamdeck"
+	"github.com/matthewpi/streamdeck/button"
+	"github.com/matthewpi/streamdeck/streamdecktest"
+	"github.com/matthewpi/streamdeck/view"
+)
+
+// productXL is the product id of the Stream Deck XL.
+const productXL = 0x6c
+
+// newAnimation returns an animated Button alternating between frames of the
+// given colors.
+func newAnimation(t *testing.T, sd *streamdeck.StreamDeck, delay time.Duration, colors ...color.Color) *button.Animation {
+	t.Helper()
+
+	frames := make([]image.Image, len(colors))
+	delays := make([]time.Duration, len(colors))
+	for i, c := range colors {
+		img := image.NewRGBA(image.Rect(0, 0, 96, 96))
+		draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+		frames[i] = img
+		delays[i] = delay
+	}
+	a, err := button.NewAnimated(sd, frames, delays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// testImage returns a unique image that spans multiple packets.
+func testImage(seed int) []byte {
+	b := make([]byte, 3000)
+	for i := range b {
+		b[i] = byte(seed + i)
+	}
+	return b
+}
+
+// TestButtonsAnimateConcurrently runs several animations while other buttons
+// are updated concurrently, and checks that the images written to the Device
+// are never interleaved. Run with -race to also check for data races.
+func TestButtonsAnimateConcurrently(t *testing.T) {
+	sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productXL))
+	// Yield between packets to give other writers a chance to interleave.
+	tr.OnWrite = func([]byte) error {
+		runtime.Gosched()
+		return nil
+	}
+
+	b, err := view.NewButtons(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const animated = 4
+	for i := 0; i < animated; i++ {
+		b.Set(i, newAnimation(t, sd, time.Millisecond, color.White, color.Black))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := sd.SetView(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+
+	// Update the remaining buttons concurrently with the animations.
+	const updates = 20
+	var wg sync.WaitGroup
+	for i := animated; i < animated+8; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < updates; j++ {
+				b.Set(i, button.NewImage(testImage(i+j)))
+				if err := b.Update(ctx, i); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	cancel()
+
+	if n := tr.Interleaved(); n != 0 {
+		t.Errorf("%d packets were interleaved with another image", n)
+	}
+	for i := 0; i < animated; i++ {
+		if n := tr.ImageCount(i); n < 2 {
+			t.Errorf("button %d: expected the animation to be running, got %d frames", i, n)
+		}
+	}
+	for i := animated; i < animated+8; i++ {
+		if got := tr.Image(i); !bytes.Equal(got, testImage(i+updates-1)) {
+			t.Errorf("button %d: expected the last update to be displayed", i)
+		}
+	}
+}
+
+// countingAnimation is an animated Button that counts how many times it is
+// animating concurrently.
+type countingAnimation struct {
+	// err is returned by Animate immediately, if set.
+	err error
+
+	mx      sync.Mutex
+	started int
+	running int
+}
+
+var _ button.Animated = (*countingAnimation)(nil)
+
+func (a *countingAnimation) Image() []byte {
+	return testImage(0)
+}
+
+func (a *countingAnimation) Animate(ctx context.Context, _ func(context.Context, []byte) error) error {
+	a.mx.Lock()
+	a.started++
+	a.running++
+	a.mx.Unlock()
+	defer func() {
+		a.mx.Lock()
+		a.running--
+		a.mx.Unlock()
+	}()
+
+	if a.err != nil {
+		return a.err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// counts returns the number of times the animation was started and the number
+// of animations that are still running.
+func (a *countingAnimation) counts() (started, running int) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	return a.started, a.running
+}
+
+// waitForCounts waits for the animation to have been started and to be
+// running the given number of times.
+func (a *countingAnimation) waitForCounts(t *testing.T, started, running int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		s, r := a.counts()
+		if s == started && r == running {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d animations started and %d running, got %d and %d", started, running, s, r)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestButtonsApplyTwice checks that applying a view again replaces the
+// animations it started rather than starting a second animation per button.
+func TestButtonsApplyTwice(t *testing.T) {
+	sd, _ := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productXL))
+	b, err := view.NewButtons(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	animations := []*countingAnimation{{}, {}, {}}
+	for i, a := range animations {
+		b.Set(i, a)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := b.Apply(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range animations {
+		a.waitForCounts(t, 1, 1)
+	}
+
+	if err := b.Apply(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range animations {
+		a.waitForCounts(t, 2, 1)
+	}
+
+	// Cancelling the context stops every animation.
+	cancel()
+	for _, a := range animations {
+		a.waitForCounts(t, 2, 0)
+	}
+}
+
+// TestButtonsAnimateError checks that animation errors are logged using the
+// Stream Deck's logger.
+func TestButtonsAnimateError(t *testing.T) {
+	var (
+		mx  sync.Mutex
+		buf bytes.Buffer
+	)
+	logger := log.New(writerFunc(func(p []byte) (int, error) {
+		mx.Lock()
+		defer mx.Unlock()
+		return buf.Write(p)
+	}), "", 0)
+	sd, _ := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productXL), streamdeck.WithLogger(logger))
+
+	b, err := view.NewButtons(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &countingAnimation{err: errors.New("decode failed")}
+	b.Set(3, a)
+	if err := sd.SetView(context.Background(), b); err != nil {
+		t.Fatal(err)
+	}
+	a.waitForCounts(t, 1, 0)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mx.Lock()
+		logged := buf.String()
+		mx.Unlock()
+		if strings.Contains(logged, "view: failed to animate button 3: decode failed") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the animation error to be logged, got %q", logged)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// writerFunc is an io.Writer calling a function.
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+// pressable is a button.Pressable that counts its presses.
+type pressable struct {
+	img     []byte
+	presses atomic.Int32
+}
+
+func (p *pressable) Image() []byte { return p.img }
+
+func (p *pressable) OnPress(context.Context) error {
+	p.presses.Add(1)
+	return nil
+}
+
+// TestButtonsSwapClearsHandler checks that presses no longer reach the buttons
+// of the previous view once a view without any button.Pressable buttons is
+// displayed.
+func TestButtonsSwapClearsHandler(t *testing.T) {
+	sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, productXL))
+	ctx := context.Background()
+
+	old, err := view.NewButtons(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &pressable{img: testImage(0)}
+	old.Set(0, p)
+	if err := sd.SetView(ctx, old); err != nil {
+		t.Fatal(err)
+	}
+	tr.Keys(0)
+	tr.Keys()
+	deadline := time.Now().Add(time.Second)
+	for p.presses.Load() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the button to be pressed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	b, err := view.NewButtons(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Set(0, button.NewImage(testImage(1)))
+	for _, apply := range []func() error{
+		func() error { return sd.SetView(ctx, b) },
+		// Applying the view directly must also clear the handler.
+		func() error { return old.Apply(ctx) },
+		func() error { return b.Apply(ctx) },
+	} {
+		if err := apply(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	images := tr.ImageCount(0)
+	tr.Keys(0)
+	tr.Keys()
+	time.Sleep(20 * time.Millisecond)
+	if n := p.presses.Load(); n != 1 {
+		t.Errorf("expected the previous view to not handle the press, got %d presses", n)
+	}
+	if n := tr.ImageCount(0); n != images {
+		t.Errorf("expected the button to not be repainted, got %d images", n-images)
+	}
+}
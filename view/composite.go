@@ -0,0 +1,130 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package view
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/matthewpi/streamdeck"
+)
+
+// Composite is an implementation of the View interface that displays multiple
+// Buttons views at once, each managing a different subset of the buttons on a
+// Stream Deck.
+//
+// Button presses are dispatched to the view that manages the pressed button.
+type Composite struct {
+	sd *streamdeck.StreamDeck
+
+	views []*Buttons
+	// owners maps the index of a button to the view that manages it.
+	owners []*Buttons
+}
+
+var (
+	_ streamdeck.View           = (*Composite)(nil)
+	_ streamdeck.PressHandler   = (*Composite)(nil)
+	_ streamdeck.ReleaseHandler = (*Composite)(nil)
+	_ streamdeck.ResetHandler   = (*Composite)(nil)
+)
+
+// NewComposite returns a Composite View displaying the given views, see
+// NewButtonsSubset and NewButtonsRange.
+//
+// An error will be returned if more than one view manages the same button.
+func NewComposite(sd *streamdeck.StreamDeck, views ...*Buttons) (*Composite, error) {
+	if sd == nil {
+		return nil, errors.New("view: streamdeck cannot be nil")
+	}
+	owners := make([]*Buttons, sd.Device().ButtonCount())
+	for _, v := range views {
+		if v == nil {
+			return nil, errors.New("view: view cannot be nil")
+		}
+		for i := range owners {
+			if !v.Owns(i) {
+				continue
+			}
+			if owners[i] != nil {
+				return nil, fmt.Errorf("view: button %d is managed by multiple views", i)
+			}
+			owners[i] = v
+		}
+	}
+	return &Composite{
+		sd:     sd,
+		views:  views,
+		owners: owners,
+	}, nil
+}
+
+// Apply updates the displayed content of every view and sets Composite#Handle
+// and Composite#HandleRelease as the Stream Deck's button press and release
+// handlers.
+func (c *Composite) Apply(ctx context.Context) error {
+	for _, v := range c.views {
+		if err := v.apply(ctx, nil); err != nil {
+			return err
+		}
+	}
+	c.sd.SetHandler(c.Handle)
+	c.sd.SetReleaseHandler(c.HandleRelease)
+	return nil
+}
+
+// Handle dispatches a button press to the view that manages the button, presses
+// on buttons that aren't managed by any view are ignored.
+func (c *Composite) Handle(ctx context.Context, index int) error {
+	if index < 0 || index >= len(c.owners) || c.owners[index] == nil {
+		return nil
+	}
+	return c.owners[index].Handle(ctx, index)
+}
+
+// HandleRelease dispatches a button release to the view that manages the
+// button, releases of buttons that aren't managed by any view are ignored.
+func (c *Composite) HandleRelease(ctx context.Context, index int) error {
+	if index < 0 || index >= len(c.owners) || c.owners[index] == nil {
+		return nil
+	}
+	return c.owners[index].HandleRelease(ctx, index)
+}
+
+// OnReset displays every view again after the Stream Deck has been reset.
+func (c *Composite) OnReset(ctx context.Context) error {
+	for _, v := range c.views {
+		if err := v.OnReset(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stops all animations started by the views.
+func (c *Composite) Close() {
+	for _, v := range c.views {
+		v.Close()
+	}
+}
@@ -0,0 +1,291 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package view
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	// Register the image formats supported by ButtonConfig.Image.
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/matthewpi/streamdeck"
+	"github.com/matthewpi/streamdeck/button"
+)
+
+// Config describes the layout of a Buttons View, it is usually decoded from a
+// JSON file using LoadConfig or LoadConfigFile.
+//
+// An example config:
+//
+//	{
+//	  "buttons": {
+//	    "R0C0": {"image": "icons/home.png", "label": "Home"},
+//	    "R0C1": {"color": "#ff0000"},
+//	    "B3": {"text": "Hello\nWorld", "textColor": "#000", "background": "#fff"},
+//	    "4": {"gif": "animations/loading.gif"}
+//	  }
+//	}
+type Config struct {
+	// Buttons maps a button to the content displayed on it. Buttons are
+	// referenced by a label, see DeviceType#ParseButtonLabel, or by their
+	// index.
+	Buttons map[string]ButtonConfig `json:"buttons"`
+}
+
+// ButtonConfig describes the content displayed on a single button, exactly one
+// of Image, Color, Text, or GIF must be set.
+type ButtonConfig struct {
+	// Image is the path to a PNG, JPEG, or GIF image displayed on the button,
+	// relative paths are resolved relative to the config file.
+	Image string `json:"image,omitempty"`
+	// Label is displayed below the Image, if set.
+	Label string `json:"label,omitempty"`
+	// Color fills the button with a solid color, in the form "#rgb",
+	// "#rrggbb", or "#rrggbbaa".
+	Color string `json:"color,omitempty"`
+	// Text is displayed centered on the button, each line is centered
+	// separately.
+	Text string `json:"text,omitempty"`
+	// GIF is the path to an animated GIF displayed on the button.
+	GIF string `json:"gif,omitempty"`
+
+	// TextColor is the color of the Text or Label, defaults to white.
+	TextColor string `json:"textColor,omitempty"`
+	// Background is the color behind the Text or Label, defaults to black.
+	Background string `json:"background,omitempty"`
+	// FontSize is the size of the Text or Label in pixels, if zero a size
+	// relative to the button will be used.
+	FontSize float64 `json:"fontSize,omitempty"`
+}
+
+// ConfigError is returned when a button in a Config is invalid or its content
+// can't be loaded.
+type ConfigError struct {
+	// Button is the key used to reference the button in the Config.
+	Button string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("view: button %q: %v", e.Button, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// LoadConfig decodes a JSON Config from r and returns a Buttons View displaying
+// it, relative paths in the Config are resolved relative to the working
+// directory.
+//
+// Every button is loaded even if another button fails, a ConfigError is
+// returned for each button that failed.
+func LoadConfig(sd *streamdeck.StreamDeck, r io.Reader) (*Buttons, error) {
+	return loadConfig(sd, r, "")
+}
+
+// LoadConfigFile is like LoadConfig except the Config is read from the file at
+// path, relative paths in the Config are resolved relative to the file.
+func LoadConfigFile(sd *streamdeck.StreamDeck, path string) (*Buttons, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return loadConfig(sd, f, filepath.Dir(path))
+}
+
+// loadConfig decodes a Config from r and returns a Buttons View displaying it,
+// relative paths are resolved relative to dir.
+func loadConfig(sd *streamdeck.StreamDeck, r io.Reader, dir string) (*Buttons, error) {
+	if sd == nil {
+		return nil, errors.New("view: streamdeck cannot be nil")
+	}
+
+	var cfg Config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("view: failed to decode config: %w", err)
+	}
+	return NewButtonsFromConfig(sd, cfg, dir)
+}
+
+// NewButtonsFromConfig returns a Buttons View displaying a Config, relative
+// paths are resolved relative to dir.
+//
+// Every button is loaded even if another button fails, a ConfigError is
+// returned for each button that failed.
+func NewButtonsFromConfig(sd *streamdeck.StreamDeck, cfg Config, dir string) (*Buttons, error) {
+	b, err := NewButtons(sd)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	seen := make(map[int]string, len(cfg.Buttons))
+	for key, bc := range cfg.Buttons {
+		index, err := parseButtonKey(sd.Device().DeviceType, key)
+		if err != nil {
+			errs = append(errs, &ConfigError{Button: key, Err: err})
+			continue
+		}
+		if other, ok := seen[index]; ok {
+			errs = append(errs, &ConfigError{Button: key, Err: fmt.Errorf("button is also configured by %q", other)})
+			continue
+		}
+		seen[index] = key
+
+		btn, err := bc.build(sd, dir)
+		if err != nil {
+			errs = append(errs, &ConfigError{Button: key, Err: err})
+			continue
+		}
+		b.Set(index, btn)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return b, nil
+}
+
+// parseButtonKey returns the index of the button referenced by a key in a
+// Config, either a label or an index.
+func parseButtonKey(t streamdeck.DeviceType, key string) (int, error) {
+	if i, err := strconv.Atoi(key); err == nil {
+		if i < 0 || i >= t.ButtonCount() {
+			return 0, fmt.Errorf("%w: %d", streamdeck.ErrInvalidButton, i)
+		}
+		return i, nil
+	}
+	return t.ParseButtonLabel(key)
+}
+
+// build returns the Button described by the ButtonConfig.
+func (c ButtonConfig) build(sd *streamdeck.StreamDeck, dir string) (button.Button, error) {
+	var set int
+	for _, v := range []string{c.Image, c.Color, c.Text, c.GIF} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("exactly one of image, color, text, or gif must be set")
+	}
+
+	opts := button.IconTextOptions{FontSize: c.FontSize}
+	if c.TextColor != "" {
+		v, err := parseColor(c.TextColor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid text color: %w", err)
+		}
+		opts.TextColor = v
+	}
+	if c.Background != "" {
+		v, err := parseColor(c.Background)
+		if err != nil {
+			return nil, fmt.Errorf("invalid background: %w", err)
+		}
+		opts.BackgroundColor = v
+	}
+
+	switch {
+	case c.Color != "":
+		v, err := parseColor(c.Color)
+		if err != nil {
+			return nil, fmt.Errorf("invalid color: %w", err)
+		}
+		return button.NewColor(sd, v)
+	case c.Text != "":
+		return button.NewText(sd, c.Text, opts)
+	case c.GIF != "":
+		f, err := os.Open(resolvePath(dir, c.GIF))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		g, err := gif.DecodeAll(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gif: %w", err)
+		}
+		return button.NewGIF(sd, g)
+	default:
+		if c.Label == "" {
+			return button.NewImageFromFile(sd, resolvePath(dir, c.Image))
+		}
+		f, err := os.Open(resolvePath(dir, c.Image))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		img, _, err := image.Decode(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode image: %w", err)
+		}
+		return button.NewIconText(sd, img, c.Label, opts)
+	}
+}
+
+// resolvePath resolves a path in a Config relative to dir.
+func resolvePath(dir, path string) string {
+	if dir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// parseColor parses a color in the form "#rgb", "#rrggbb", or "#rrggbbaa".
+func parseColor(s string) (color.Color, error) {
+	hex, ok := strings.CutPrefix(s, "#")
+	if !ok {
+		return nil, fmt.Errorf("color %q must start with #", s)
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return nil, fmt.Errorf("color %q has an invalid length", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("color %q is not valid hex", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
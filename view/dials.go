@@ -0,0 +1,195 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package view
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/matthewpi/streamdeck"
+	"github.com/matthewpi/streamdeck/button"
+)
+
+// DialConfig configures the value controlled by a dial.
+type DialConfig struct {
+	// Min is the minimum value.
+	Min float64
+	// Max is the maximum value.
+	Max float64
+	// Step is the amount the value changes by for each step the dial is
+	// rotated, defaults to 1.
+	Step float64
+	// Value is the initial value, it will be clamped to [Min, Max].
+	Value float64
+
+	// Acceleration increases the amount the value changes by when the dial is
+	// rotated quickly, the Step is multiplied by 1 + Acceleration for every
+	// additional step reported in a single event. Zero disables acceleration.
+	Acceleration float64
+
+	// OnChange is called with the new value whenever it changes.
+	OnChange func(ctx context.Context, value float64) error
+
+	// Format is used to render the value on the touchscreen segment above the
+	// dial using fmt.Sprintf, like "%.0f%%". If empty, the value will not be
+	// rendered.
+	Format string
+	// TextOptions are used to render the value on the touchscreen.
+	TextOptions button.IconTextOptions
+}
+
+// DialControls maps each dial on a Stream Deck to a bounded value.
+//
+// DialControls#Apply must be called to start handling dial events.
+type DialControls struct {
+	sd *streamdeck.StreamDeck
+
+	valuesMx sync.Mutex
+	configs  []DialConfig
+	values   []float64
+}
+
+// NewDialControls returns DialControls mapping each dial to a value, configs
+// are assigned to dials from left to right.
+func NewDialControls(sd *streamdeck.StreamDeck, configs []DialConfig) (*DialControls, error) {
+	if sd == nil {
+		return nil, errors.New("view: streamdeck cannot be nil")
+	}
+	if !sd.Device().HasDials() {
+		return nil, errors.New("view: streamdeck does not have any dials")
+	}
+	if len(configs) > sd.Device().Dials {
+		return nil, fmt.Errorf("view: %d dial configs provided but the streamdeck only has %d dials", len(configs), sd.Device().Dials)
+	}
+
+	d := &DialControls{
+		sd:      sd,
+		configs: make([]DialConfig, len(configs)),
+		values:  make([]float64, len(configs)),
+	}
+	for i, c := range configs {
+		if c.Min > c.Max {
+			return nil, fmt.Errorf("view: dial %d has a minimum greater than its maximum", i)
+		}
+		if c.Step == 0 {
+			c.Step = 1
+		}
+		d.configs[i] = c
+		d.values[i] = clamp(c.Value, c.Min, c.Max)
+	}
+	return d, nil
+}
+
+// Apply sets DialControls#Handle as the Stream Deck's dial handler and renders
+// the value of every dial on the touchscreen.
+func (d *DialControls) Apply(ctx context.Context) error {
+	d.sd.SetDialHandler(d.Handle)
+	for i := range d.configs {
+		if err := d.render(ctx, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Value returns the current value of a dial.
+func (d *DialControls) Value(index int) float64 {
+	d.valuesMx.Lock()
+	defer d.valuesMx.Unlock()
+	if index < 0 || index >= len(d.values) {
+		return 0
+	}
+	return d.values[index]
+}
+
+// SetValue sets the value of a dial, the value will be clamped to the dial's
+// range. OnChange is not called.
+func (d *DialControls) SetValue(ctx context.Context, index int, value float64) error {
+	if index < 0 || index >= len(d.configs) {
+		return errors.New("view: dial out of range")
+	}
+
+	d.valuesMx.Lock()
+	c := d.configs[index]
+	d.values[index] = clamp(value, c.Min, c.Max)
+	d.valuesMx.Unlock()
+	return d.render(ctx, index)
+}
+
+// Handle handles a dial event, updating the value of the dial when it is
+// rotated.
+func (d *DialControls) Handle(ctx context.Context, event streamdeck.DialEvent) error {
+	if event.Kind != streamdeck.DialRotate || event.Index < 0 || event.Index >= len(d.configs) {
+		return nil
+	}
+
+	d.valuesMx.Lock()
+	c := d.configs[event.Index]
+	steps := math.Abs(float64(event.Delta))
+	change := c.Step * float64(event.Delta) * (1 + c.Acceleration*(steps-1))
+	old := d.values[event.Index]
+	value := clamp(old+change, c.Min, c.Max)
+	d.values[event.Index] = value
+	d.valuesMx.Unlock()
+
+	if value == old {
+		return nil
+	}
+	if err := d.render(ctx, event.Index); err != nil {
+		return err
+	}
+	if c.OnChange == nil {
+		return nil
+	}
+	return c.OnChange(ctx, value)
+}
+
+// render renders the value of a dial on the touchscreen segment above it.
+func (d *DialControls) render(ctx context.Context, index int) error {
+	c := d.configs[index]
+	if c.Format == "" {
+		return nil
+	}
+	device := d.sd.Device()
+	if !device.HasTouchscreen() {
+		return nil
+	}
+
+	img, err := button.RenderText(device.TouchStripSegment(index).Size(), fmt.Sprintf(c.Format, d.Value(index)), c.TextOptions)
+	if err != nil {
+		return err
+	}
+	v, err := device.EncodeTouchStripImage(img)
+	if err != nil {
+		return err
+	}
+	return device.SetTouchStrip(ctx, index, v)
+}
+
+// clamp clamps v to the range [min, max].
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
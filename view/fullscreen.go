@@ -0,0 +1,124 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package view
+
+import (
+	"context"
+	"errors"
+	"image"
+	"sync"
+
+	"github.com/disintegration/gift"
+
+	"github.com/matthewpi/streamdeck"
+)
+
+// fullscreenGapRatio is the size of the physical gap between buttons relative
+// to the size of a button's image.
+const fullscreenGapRatio = 0.25
+
+// Fullscreen is an implementation of the View interface that displays a
+// single image spanning every button on a Stream Deck.
+type Fullscreen struct {
+	sd *streamdeck.StreamDeck
+
+	tilesMx sync.Mutex
+	tiles   [][]byte
+}
+
+var (
+	_ streamdeck.View         = (*Fullscreen)(nil)
+	_ streamdeck.ResetHandler = (*Fullscreen)(nil)
+)
+
+// NewFullscreen returns a Fullscreen View displaying an image across every
+// button on the Stream Deck.
+func NewFullscreen(sd *streamdeck.StreamDeck, img image.Image) (*Fullscreen, error) {
+	if sd == nil {
+		return nil, errors.New("view: streamdeck cannot be nil")
+	}
+	f := &Fullscreen{sd: sd}
+	if err := f.SetImage(img); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Apply updates the displayed content for all buttons on the Stream Deck.
+func (f *Fullscreen) Apply(ctx context.Context) error {
+	f.tilesMx.Lock()
+	defer f.tilesMx.Unlock()
+
+	return f.sd.Device().SetButtonsSlice(ctx, f.tiles)
+}
+
+// OnReset displays the image again after the Stream Deck has been reset.
+func (f *Fullscreen) OnReset(ctx context.Context) error {
+	return f.Apply(ctx)
+}
+
+// SetImage sets the image displayed by the view, it will not render the image
+// on a Stream Deck, a separate call to View#Apply is required to actually
+// apply the change.
+//
+// The image is scaled to fill the entire Stream Deck and split into a tile for
+// each button, the area of the image behind the gaps between buttons is
+// cropped out so the image lines up across the buttons.
+//
+// This method is safe to call concurrently.
+func (f *Fullscreen) SetImage(img image.Image) error {
+	if img == nil {
+		return errors.New("view: image cannot be nil")
+	}
+
+	t := f.sd.Device().DeviceType
+	size := t.ImageDimensions()
+	gap := image.Pt(int(float64(size.X)*fullscreenGapRatio), int(float64(size.Y)*fullscreenGapRatio))
+	pitch := size.Add(gap)
+
+	// Scale the image to cover every button, including the gaps between them.
+	width := t.Cols*pitch.X - gap.X
+	height := t.Rows*pitch.Y - gap.Y
+	g := gift.New(gift.ResizeToFill(width, height, gift.LanczosResampling, gift.CenterAnchor))
+	canvas := image.NewRGBA(g.Bounds(img.Bounds()))
+	g.Draw(canvas, img)
+
+	// Process each tile individually so the Device's ImageFlags are applied to
+	// every tile rather than the image as a whole.
+	tiles := make([][]byte, t.ButtonCount())
+	for i := range tiles {
+		row, col := t.RowCol(i)
+		x, y := col*pitch.X, row*pitch.Y
+		tile := canvas.SubImage(image.Rect(x, y, x+size.X, y+size.Y))
+		rawImage, err := f.sd.ProcessImage(tile)
+		if err != nil {
+			return err
+		}
+		tiles[i] = rawImage
+	}
+
+	f.tilesMx.Lock()
+	f.tiles = tiles
+	f.tilesMx.Unlock()
+	return nil
+}
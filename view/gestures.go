@@ -0,0 +1,243 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package view
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLongPress is the default amount of time a button must be held to
+	// be considered a long press.
+	DefaultLongPress = 500 * time.Millisecond
+	// DefaultDoublePress is the default amount of time allowed between two
+	// taps for them to be considered a double press.
+	DefaultDoublePress = 250 * time.Millisecond
+)
+
+// GestureOptions are options used to classify button gestures.
+type GestureOptions struct {
+	// LongPress is the amount of time a button must be held to be considered a
+	// long press, defaults to DefaultLongPress.
+	LongPress time.Duration
+
+	// DoublePress is the amount of time allowed between two taps for them to
+	// be considered a double press, defaults to DefaultDoublePress.
+	DoublePress time.Duration
+
+	// Debounce is the amount of time after a button is released where presses
+	// will be ignored.
+	Debounce time.Duration
+
+	// OnTap is called when a button is tapped.
+	OnTap func(context.Context, int) error
+
+	// OnDoublePress is called when a button is tapped twice in a row. If nil,
+	// OnTap will be called immediately without waiting for a second tap.
+	OnDoublePress func(context.Context, int) error
+
+	// OnLongPress is called when a button is held.
+	OnLongPress func(context.Context, int) error
+
+	// OnError is called if any of the callbacks return an error.
+	OnError func(error)
+}
+
+// Gestures classifies button presses and releases into taps, double presses,
+// and long presses.
+//
+// Gestures#Press and Gestures#Release should be set as a Stream Deck's press
+// and release handlers respectively.
+type Gestures struct {
+	opts GestureOptions
+
+	// now returns the current time, it can be replaced by tests.
+	now func() time.Time
+	// afterFunc calls fn in its own goroutine after d has elapsed, it can be
+	// replaced by tests.
+	afterFunc func(d time.Duration, fn func()) timer
+
+	statesMx sync.Mutex
+	states   map[int]*gestureState
+}
+
+// gestureState is the state used to classify gestures for a single button.
+type gestureState struct {
+	// pressedAt is the time the button was last pressed, zero if the button is
+	// not pressed.
+	pressedAt time.Time
+	// releasedAt is the time the button was last released.
+	releasedAt time.Time
+	// longPress fires when the button has been held for long enough.
+	longPress timer
+	// longPressed is true if a long press was triggered for the current press.
+	longPressed bool
+	// tap fires when the double press window has passed.
+	tap timer
+}
+
+// timer is a timer started by Gestures#afterFunc.
+type timer interface {
+	// Stop prevents the timer from firing, returning false if the timer has
+	// already fired or been stopped.
+	Stop() bool
+}
+
+// NewGestures returns a new Gestures classifier using the given options.
+func NewGestures(opts GestureOptions) *Gestures {
+	if opts.LongPress <= 0 {
+		opts.LongPress = DefaultLongPress
+	}
+	if opts.DoublePress <= 0 {
+		opts.DoublePress = DefaultDoublePress
+	}
+	return &Gestures{
+		opts: opts,
+		now:  time.Now,
+		afterFunc: func(d time.Duration, fn func()) timer {
+			return time.AfterFunc(d, fn)
+		},
+		states: make(map[int]*gestureState),
+	}
+}
+
+// Press handles a button being pressed.
+func (g *Gestures) Press(ctx context.Context, index int) error {
+	g.statesMx.Lock()
+	defer g.statesMx.Unlock()
+
+	s := g.state(index)
+	now := g.now()
+	if !s.pressedAt.IsZero() || now.Sub(s.releasedAt) < g.opts.Debounce {
+		return nil
+	}
+	s.pressedAt = now
+	s.longPressed = false
+	s.longPress = g.afterFunc(g.opts.LongPress, func() {
+		g.statesMx.Lock()
+		if !s.pressedAt.Equal(now) {
+			g.statesMx.Unlock()
+			return
+		}
+		s.longPressed = true
+		// Discard any pending tap, it can no longer become a double press.
+		if s.tap != nil {
+			s.tap.Stop()
+			s.tap = nil
+		}
+		g.statesMx.Unlock()
+		g.call(ctx, g.opts.OnLongPress, index)
+	})
+	return nil
+}
+
+// Release handles a button being released.
+func (g *Gestures) Release(ctx context.Context, index int) error {
+	g.statesMx.Lock()
+	s := g.state(index)
+	if s.pressedAt.IsZero() {
+		g.statesMx.Unlock()
+		return nil
+	}
+	s.pressedAt = time.Time{}
+	s.releasedAt = g.now()
+	if s.longPress != nil {
+		s.longPress.Stop()
+		s.longPress = nil
+	}
+	if s.longPressed {
+		g.statesMx.Unlock()
+		return nil
+	}
+
+	// A tap is already pending, so this is the second tap of a double press.
+	if s.tap != nil {
+		s.tap.Stop()
+		s.tap = nil
+		g.statesMx.Unlock()
+		g.call(ctx, g.opts.OnDoublePress, index)
+		return nil
+	}
+
+	if g.opts.OnDoublePress == nil {
+		g.statesMx.Unlock()
+		g.call(ctx, g.opts.OnTap, index)
+		return nil
+	}
+
+	var tap timer
+	tap = g.afterFunc(g.opts.DoublePress, func() {
+		g.statesMx.Lock()
+		if s.tap != tap {
+			g.statesMx.Unlock()
+			return
+		}
+		s.tap = nil
+		g.statesMx.Unlock()
+		g.call(ctx, g.opts.OnTap, index)
+	})
+	s.tap = tap
+	g.statesMx.Unlock()
+	return nil
+}
+
+// Stop stops all pending timers, no further callbacks will be called for any
+// presses that have not yet been classified.
+func (g *Gestures) Stop() {
+	g.statesMx.Lock()
+	defer g.statesMx.Unlock()
+
+	for _, s := range g.states {
+		if s.longPress != nil {
+			s.longPress.Stop()
+			s.longPress = nil
+		}
+		if s.tap != nil {
+			s.tap.Stop()
+			s.tap = nil
+		}
+		s.pressedAt = time.Time{}
+	}
+}
+
+// state returns the gestureState for a button, g.statesMx must be held.
+func (g *Gestures) state(index int) *gestureState {
+	s, ok := g.states[index]
+	if !ok {
+		s = &gestureState{}
+		g.states[index] = s
+	}
+	return s
+}
+
+// call calls a gesture callback, unless the context has been cancelled.
+func (g *Gestures) call(ctx context.Context, fn func(context.Context, int) error, index int) {
+	if fn == nil || ctx.Err() != nil {
+		return
+	}
+	if err := fn(ctx, index); err != nil && g.opts.OnError != nil {
+		g.opts.OnError(err)
+	}
+}
@@ -0,0 +1,237 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package view
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that only moves when it is advanced, timers started
+// using fakeClock#AfterFunc fire synchronously from fakeClock#Advance.
+type fakeClock struct {
+	mx     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// fakeTimer is a timer started by fakeClock#AfterFunc.
+type fakeTimer struct {
+	c       *fakeClock
+	at      time.Time
+	fn      func()
+	stopped bool
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, fn func()) timer {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	t := &fakeTimer{c: c, at: c.now.Add(d), fn: fn}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, firing any timers that expire in the
+// order they expire.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mx.Lock()
+	end := c.now.Add(d)
+	for {
+		sort.SliceStable(c.timers, func(i, j int) bool {
+			return c.timers[i].at.Before(c.timers[j].at)
+		})
+		if len(c.timers) == 0 || c.timers[0].at.After(end) {
+			break
+		}
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		c.now = t.at
+		t.stopped = true
+		c.mx.Unlock()
+		t.fn()
+		c.mx.Lock()
+	}
+	c.now = end
+	c.mx.Unlock()
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.c.mx.Lock()
+	defer t.c.mx.Unlock()
+
+	if t.stopped {
+		return false
+	}
+	t.stopped = true
+	for i, v := range t.c.timers {
+		if v == t {
+			t.c.timers = append(t.c.timers[:i], t.c.timers[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// gesture is a gesture recognised by Gestures.
+type gesture struct {
+	kind  string
+	index int
+}
+
+// newTestGestures returns Gestures using a fake clock, recording every
+// gesture in the returned slice.
+func newTestGestures(opts GestureOptions, doublePress bool) (*Gestures, *fakeClock, *[]gesture) {
+	var gestures []gesture
+	record := func(kind string) func(context.Context, int) error {
+		return func(_ context.Context, index int) error {
+			gestures = append(gestures, gesture{kind: kind, index: index})
+			return nil
+		}
+	}
+	opts.OnTap = record("tap")
+	opts.OnLongPress = record("long")
+	if doublePress {
+		opts.OnDoublePress = record("double")
+	}
+
+	c := &fakeClock{now: time.Unix(0, 0)}
+	g := NewGestures(opts)
+	g.now = c.Now
+	g.afterFunc = c.AfterFunc
+	return g, c, &gestures
+}
+
+func checkGestures(t *testing.T, got *[]gesture, want ...gesture) {
+	t.Helper()
+
+	if len(*got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("expected gestures %v, got %v", want, *got)
+	}
+}
+
+func TestGesturesTap(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("without double press", func(t *testing.T) {
+		g, c, got := newTestGestures(GestureOptions{}, false)
+		_ = g.Press(ctx, 3)
+		c.Advance(DefaultLongPress - time.Millisecond)
+		_ = g.Release(ctx, 3)
+		checkGestures(t, got, gesture{"tap", 3})
+
+		// Releasing cancels the long press.
+		c.Advance(time.Second)
+		checkGestures(t, got, gesture{"tap", 3})
+	})
+
+	t.Run("with double press", func(t *testing.T) {
+		g, c, got := newTestGestures(GestureOptions{}, true)
+		_ = g.Press(ctx, 3)
+		c.Advance(50 * time.Millisecond)
+		_ = g.Release(ctx, 3)
+
+		// The tap is only reported once a second tap can no longer happen.
+		c.Advance(DefaultDoublePress - time.Millisecond)
+		checkGestures(t, got)
+		c.Advance(time.Millisecond)
+		checkGestures(t, got, gesture{"tap", 3})
+	})
+
+	t.Run("debounce", func(t *testing.T) {
+		g, c, got := newTestGestures(GestureOptions{Debounce: 20 * time.Millisecond}, false)
+		_ = g.Press(ctx, 1)
+		_ = g.Release(ctx, 1)
+		c.Advance(10 * time.Millisecond)
+		_ = g.Press(ctx, 1)
+		_ = g.Release(ctx, 1)
+		checkGestures(t, got, gesture{"tap", 1})
+
+		c.Advance(10 * time.Millisecond)
+		_ = g.Press(ctx, 1)
+		_ = g.Release(ctx, 1)
+		checkGestures(t, got, gesture{"tap", 1}, gesture{"tap", 1})
+	})
+}
+
+func TestGesturesDoublePress(t *testing.T) {
+	ctx := context.Background()
+	g, c, got := newTestGestures(GestureOptions{}, true)
+
+	_ = g.Press(ctx, 2)
+	c.Advance(50 * time.Millisecond)
+	_ = g.Release(ctx, 2)
+	c.Advance(DefaultDoublePress - 60*time.Millisecond)
+	_ = g.Press(ctx, 2)
+	c.Advance(50 * time.Millisecond)
+	_ = g.Release(ctx, 2)
+	checkGestures(t, got, gesture{"double", 2})
+
+	// The first tap must not also be reported.
+	c.Advance(time.Second)
+	checkGestures(t, got, gesture{"double", 2})
+
+	// Taps on different buttons are never a double press.
+	_ = g.Press(ctx, 0)
+	_ = g.Release(ctx, 0)
+	_ = g.Press(ctx, 1)
+	_ = g.Release(ctx, 1)
+	c.Advance(DefaultDoublePress)
+	checkGestures(t, got, gesture{"double", 2}, gesture{"tap", 0}, gesture{"tap", 1})
+}
+
+func TestGesturesLongPress(t *testing.T) {
+	ctx := context.Background()
+	g, c, got := newTestGestures(GestureOptions{LongPress: time.Second}, true)
+
+	_ = g.Press(ctx, 5)
+	c.Advance(time.Second - time.Millisecond)
+	checkGestures(t, got)
+	c.Advance(time.Millisecond)
+	checkGestures(t, got, gesture{"long", 5})
+
+	// Releasing after a long press is not a tap.
+	c.Advance(time.Second)
+	_ = g.Release(ctx, 5)
+	c.Advance(time.Second)
+	checkGestures(t, got, gesture{"long", 5})
+
+	// Stop discards a press that hasn't been classified yet.
+	_ = g.Press(ctx, 5)
+	g.Stop()
+	c.Advance(2 * time.Second)
+	checkGestures(t, got, gesture{"long", 5})
+}
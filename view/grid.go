@@ -0,0 +1,116 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package view
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/matthewpi/streamdeck"
+	"github.com/matthewpi/streamdeck/button"
+)
+
+// Grid is a builder used to declaratively lay out a Buttons View using the
+// row and column of each button.
+type Grid struct {
+	sd *streamdeck.StreamDeck
+
+	buttons  map[int]button.Button
+	handlers map[int]func(context.Context) error
+	err      error
+}
+
+// NewGrid returns a new Grid builder for the Stream Deck.
+func NewGrid(sd *streamdeck.StreamDeck) *Grid {
+	return &Grid{
+		sd:       sd,
+		buttons:  make(map[int]button.Button),
+		handlers: make(map[int]func(context.Context) error),
+	}
+}
+
+// Button sets the Button displayed at the given row and column.
+func (g *Grid) Button(row, col int, btn button.Button) *Grid {
+	if index, ok := g.index(row, col); ok {
+		g.buttons[index] = btn
+	}
+	return g
+}
+
+// OnPress sets the function called when the button at the given row and column
+// is pressed.
+func (g *Grid) OnPress(row, col int, fn func(context.Context) error) *Grid {
+	if index, ok := g.index(row, col); ok {
+		g.handlers[index] = fn
+	}
+	return g
+}
+
+// Build returns a Buttons View containing all the buttons added to the Grid,
+// with a press handler that calls the function set for each button.
+//
+// An error will be returned if any of the buttons were added outside the
+// Stream Deck's grid.
+func (g *Grid) Build() (*Buttons, error) {
+	if g.sd == nil {
+		return nil, errors.New("view: streamdeck cannot be nil")
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	b, err := NewButtons(g.sd)
+	if err != nil {
+		return nil, err
+	}
+	for i, btn := range g.buttons {
+		b.Set(i, btn)
+	}
+
+	handlers := make(map[int]func(context.Context) error, len(g.handlers))
+	for i, fn := range g.handlers {
+		handlers[i] = fn
+	}
+	b.SetHandler(func(ctx context.Context, index int) error {
+		fn, ok := handlers[index]
+		if !ok || fn == nil {
+			return nil
+		}
+		return fn(ctx)
+	})
+	return b, nil
+}
+
+// index returns the index of the button at the given row and column, recording
+// an error if the position is outside the Stream Deck's grid.
+func (g *Grid) index(row, col int) (int, bool) {
+	if g.sd == nil {
+		return 0, false
+	}
+	index, ok := g.sd.Device().ButtonAt(row, col)
+	if !ok && g.err == nil {
+		g.err = fmt.Errorf("view: no button at row %d, column %d", row, col)
+	}
+	return index, ok
+}
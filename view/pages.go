@@ -0,0 +1,235 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package view
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/matthewpi/streamdeck"
+	"github.com/matthewpi/streamdeck/button"
+)
+
+// Pages is an implementation of the View interface that displays one of
+// multiple Buttons views at a time, reserving two buttons used to navigate
+// to the previous and next page.
+type Pages struct {
+	sd *streamdeck.StreamDeck
+
+	prev int
+	next int
+
+	pagesMx    sync.Mutex
+	pages      []*Buttons
+	current    int
+	prevButton button.Button
+	nextButton button.Button
+	// ctx is the context passed to Pages#Apply, pages are displayed using a
+	// context derived from it so their animations stop when the view is
+	// replaced.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+var (
+	_ streamdeck.View           = (*Pages)(nil)
+	_ streamdeck.PressHandler   = (*Pages)(nil)
+	_ streamdeck.ReleaseHandler = (*Pages)(nil)
+	_ streamdeck.ResetHandler   = (*Pages)(nil)
+)
+
+// NewPages returns a Pages View that uses the buttons at the prev and next
+// indexes to navigate between pages.
+//
+// Any buttons set on a page at the prev or next indexes will never be
+// displayed or receive press events.
+func NewPages(sd *streamdeck.StreamDeck, prev, next int) (*Pages, error) {
+	if sd == nil {
+		return nil, errors.New("view: streamdeck cannot be nil")
+	}
+	count := sd.Device().ButtonCount()
+	if prev < 0 || prev >= count || next < 0 || next >= count {
+		return nil, errors.New("view: navigation button out of range")
+	}
+	if prev == next {
+		return nil, errors.New("view: navigation buttons must be different")
+	}
+	return &Pages{
+		sd:   sd,
+		prev: prev,
+		next: next,
+	}, nil
+}
+
+// Add adds a page to the view. Pages are displayed in the order they are
+// added.
+//
+// This method is safe to call concurrently.
+func (p *Pages) Add(page *Buttons) *Pages {
+	p.pagesMx.Lock()
+	p.pages = append(p.pages, page)
+	p.pagesMx.Unlock()
+	return p
+}
+
+// SetNavigation sets the Buttons displayed for the previous and next page
+// navigation buttons. A nil Button will display a blank image.
+//
+// This method is safe to call concurrently.
+func (p *Pages) SetNavigation(prev, next button.Button) *Pages {
+	p.pagesMx.Lock()
+	p.prevButton = prev
+	p.nextButton = next
+	p.pagesMx.Unlock()
+	return p
+}
+
+// Current returns the index of the page that is currently displayed.
+func (p *Pages) Current() int {
+	p.pagesMx.Lock()
+	defer p.pagesMx.Unlock()
+	return p.current
+}
+
+// Apply displays the current page on the Stream Deck and sets the Stream
+// Deck's button press and release handlers to handle navigation.
+func (p *Pages) Apply(ctx context.Context) error {
+	p.sd.SetHandler(p.Handle)
+	p.sd.SetReleaseHandler(p.HandleRelease)
+
+	p.pagesMx.Lock()
+	p.ctx = ctx
+	p.pagesMx.Unlock()
+	return p.SetPage(ctx, p.Current())
+}
+
+// OnReset displays the current page again after the Stream Deck has been
+// reset.
+func (p *Pages) OnReset(ctx context.Context) error {
+	p.pagesMx.Lock()
+	p.ctx = ctx
+	p.pagesMx.Unlock()
+	return p.SetPage(ctx, p.Current())
+}
+
+// SetPage displays the page at the given index.
+//
+// Once the view has been applied, the page is displayed using the context
+// passed to Pages#Apply rather than ctx, so any animations on the page are
+// stopped when the view is replaced.
+func (p *Pages) SetPage(ctx context.Context, index int) error {
+	p.pagesMx.Lock()
+	defer p.pagesMx.Unlock()
+
+	if index < 0 || index >= len(p.pages) {
+		return errors.New("view: page out of range")
+	}
+	if p.ctx != nil {
+		ctx = p.ctx
+	}
+
+	// Stop any animations on the page that is currently displayed.
+	if p.cancel != nil {
+		p.cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+	p.current = index
+
+	page := p.pages[index]
+	if err := page.apply(ctx, p.isNavigation); err != nil {
+		return err
+	}
+	if err := page.show(ctx, p.prev, p.prevButton); err != nil {
+		return err
+	}
+	return page.show(ctx, p.next, p.nextButton)
+}
+
+// Prev displays the previous page, wrapping around to the last page.
+func (p *Pages) Prev(ctx context.Context) error {
+	return p.move(ctx, -1)
+}
+
+// Next displays the next page, wrapping around to the first page.
+func (p *Pages) Next(ctx context.Context) error {
+	return p.move(ctx, 1)
+}
+
+func (p *Pages) move(ctx context.Context, delta int) error {
+	p.pagesMx.Lock()
+	count := len(p.pages)
+	current := p.current
+	p.pagesMx.Unlock()
+
+	if count == 0 {
+		return errors.New("view: no pages")
+	}
+	return p.SetPage(ctx, (current+delta+count)%count)
+}
+
+func (p *Pages) isNavigation(index int) bool {
+	return index == p.prev || index == p.next
+}
+
+// Handle handles a button press, navigating between pages if a navigation
+// button was pressed or passing the press to the current page.
+func (p *Pages) Handle(ctx context.Context, index int) error {
+	switch index {
+	case p.prev:
+		return p.Prev(ctx)
+	case p.next:
+		return p.Next(ctx)
+	}
+
+	p.pagesMx.Lock()
+	var page *Buttons
+	if p.current < len(p.pages) {
+		page = p.pages[p.current]
+	}
+	p.pagesMx.Unlock()
+	if page == nil {
+		return nil
+	}
+	return page.Handle(ctx, index)
+}
+
+// HandleRelease handles a button release, releases of the navigation buttons
+// are ignored and any other release is passed to the current page.
+func (p *Pages) HandleRelease(ctx context.Context, index int) error {
+	if index == p.prev || index == p.next {
+		return nil
+	}
+
+	p.pagesMx.Lock()
+	var page *Buttons
+	if p.current < len(p.pages) {
+		page = p.pages[p.current]
+	}
+	p.pagesMx.Unlock()
+	if page == nil {
+		return nil
+	}
+	return page.HandleRelease(ctx, index)
+}
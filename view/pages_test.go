@@ -0,0 +1,105 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package view_test
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/streamdeck/button"
+	"github.com/matthewpi/streamdeck/streamdecktest"
+	"github.com/matthewpi/streamdeck/view"
+)
+
+// TestPagesNavigationUsesApplyContext checks that animations on a page
+// displayed by pressing a navigation button are stopped when the view is
+// replaced, even though the press handler's context outlives the view.
+func TestPagesNavigationUsesApplyContext(t *testing.T) {
+	sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, 0x60))
+
+	frames := make([]image.Image, 2)
+	for i, c := range []color.Color{color.White, color.Black} {
+		img := image.NewRGBA(image.Rect(0, 0, 72, 72))
+		draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+		frames[i] = img
+	}
+	animation, err := button.NewAnimated(sd, frames, []time.Duration{5 * time.Millisecond, 5 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := view.NewButtons(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := view.NewButtons(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second.Set(2, animation)
+	pages, err := view.NewPages(sd, 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages.Add(first).Add(second)
+
+	ctx := context.Background()
+	if err := sd.SetView(ctx, pages); err != nil {
+		t.Fatal(err)
+	}
+
+	// Navigate using a context that outlives the view, like the one passed to
+	// the press handler.
+	if err := pages.Handle(ctx, 4); err != nil {
+		t.Fatal(err)
+	}
+	start := tr.ImageCount(2)
+	deadline := time.Now().Add(time.Second)
+	for tr.ImageCount(2) < start+2 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the animation on the second page to be running")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	other, err := view.NewButtons(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sd.SetView(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+
+	// Allow a frame that was being written when the view was replaced to
+	// finish before counting.
+	time.Sleep(20 * time.Millisecond)
+	stopped := tr.ImageCount(2)
+	time.Sleep(100 * time.Millisecond)
+	if n := tr.ImageCount(2); n != stopped {
+		t.Errorf("expected the animation to stop when the view was replaced, %d frames were written", n-stopped)
+	}
+}
@@ -0,0 +1,174 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package view
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+
+	"github.com/matthewpi/streamdeck"
+)
+
+// TransitionKind represents a type of transition between two sets of images
+// displayed on a Stream Deck.
+type TransitionKind uint8
+
+const (
+	// TransitionNone instantly replaces the images.
+	TransitionNone TransitionKind = iota
+	// TransitionFade crossfades between the images.
+	TransitionFade
+	// TransitionSlideLeft slides the new images in from the right.
+	TransitionSlideLeft
+	// TransitionSlideRight slides the new images in from the left.
+	TransitionSlideRight
+)
+
+// transitionFrameInterval is the time between each frame of a transition.
+const transitionFrameInterval = 50 * time.Millisecond
+
+// Transition transitions every button on a Stream Deck from one set of images
+// to another over the given duration.
+//
+// Both from and to must contain an image processed by StreamDeck#ProcessImage
+// (or nil for a blank image) for every button on the Stream Deck. Once the
+// transition has finished, the images in to will be displayed.
+func Transition(ctx context.Context, sd *streamdeck.StreamDeck, from, to [][]byte, kind TransitionKind, d time.Duration) error {
+	device := sd.Device()
+	count := device.ButtonCount()
+	if len(from) != count || len(to) != count {
+		return errors.New("view: transition images must match the number of buttons")
+	}
+
+	// Devices without displays can't show any images.
+	if !device.HasDisplay() {
+		return nil
+	}
+	if kind == TransitionNone || d <= 0 {
+		return device.SetButtonsSlice(ctx, to)
+	}
+
+	fromImages, err := decodeAll(sd, from)
+	if err != nil {
+		return err
+	}
+	toImages, err := decodeAll(sd, to)
+	if err != nil {
+		return err
+	}
+
+	frames := int(d / transitionFrameInterval)
+	ticker := time.NewTicker(transitionFrameInterval)
+	defer ticker.Stop()
+	for f := 1; f < frames; f++ {
+		progress := float64(f) / float64(frames)
+		for i := 0; i < count; i++ {
+			var img image.Image
+			switch kind {
+			case TransitionFade:
+				img = fadeFrame(fromImages[i], toImages[i], progress)
+			case TransitionSlideLeft, TransitionSlideRight:
+				img = slideFrame(device.DeviceType, fromImages, toImages, i, kind, progress)
+			default:
+				return device.SetButtonsSlice(ctx, to)
+			}
+
+			rawImage, err := sd.ProcessImage(img)
+			if err != nil {
+				return err
+			}
+			if err := device.SetButton(ctx, i, rawImage); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return device.SetButtonsSlice(ctx, to)
+}
+
+// decodeAll decodes images processed by StreamDeck#ProcessImage.
+func decodeAll(sd *streamdeck.StreamDeck, images [][]byte) ([]image.Image, error) {
+	res := make([]image.Image, len(images))
+	for i, v := range images {
+		img, err := sd.Device().DecodeImage(v)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = img
+	}
+	return res, nil
+}
+
+// fadeFrame blends two images together, a progress of 0 returns the from
+// image and a progress of 1 returns the to image.
+func fadeFrame(from, to image.Image, progress float64) image.Image {
+	img := image.NewRGBA(from.Bounds())
+	draw.Draw(img, img.Bounds(), from, from.Bounds().Min, draw.Src)
+	mask := image.NewUniform(color.Alpha{A: uint8(progress * 0xff)})
+	draw.DrawMask(img, img.Bounds(), to, to.Bounds().Min, mask, image.Point{}, draw.Over)
+	return img
+}
+
+// slideFrame renders the image for a single button while sliding the images
+// across each row of buttons.
+func slideFrame(t streamdeck.DeviceType, from, to []image.Image, index int, kind TransitionKind, progress float64) image.Image {
+	size := t.ImageDimensions()
+	row, col := t.RowCol(index)
+	width := t.Cols * size.X
+
+	// strip is the row of images being slid across the buttons, the images
+	// being slid in are placed on the side they enter from.
+	strip := make([]image.Image, 0, t.Cols*2)
+	offset := int(progress * float64(width))
+	if kind == TransitionSlideLeft {
+		strip = append(strip, from[row*t.Cols:(row+1)*t.Cols]...)
+		strip = append(strip, to[row*t.Cols:(row+1)*t.Cols]...)
+	} else {
+		strip = append(strip, to[row*t.Cols:(row+1)*t.Cols]...)
+		strip = append(strip, from[row*t.Cols:(row+1)*t.Cols]...)
+		offset = width - offset
+	}
+
+	// x is the position of the button within the strip.
+	x := col*size.X + offset
+	img := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	for j, src := range strip {
+		pos := j*size.X - x
+		if pos <= -size.X || pos >= size.X {
+			continue
+		}
+		r := image.Rect(pos, 0, pos+size.X, size.Y).Intersect(img.Bounds())
+		draw.Draw(img, r, src, src.Bounds().Min.Add(r.Min.Sub(image.Pt(pos, 0))), draw.Src)
+	}
+	return img
+}
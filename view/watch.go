@@ -0,0 +1,124 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package view
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/matthewpi/streamdeck"
+)
+
+// watchDelay is how long to wait after a config file changes before reloading
+// it, editors often write a file using multiple operations.
+const watchDelay = 100 * time.Millisecond
+
+// WatchConfig loads the config file at path using LoadConfigFile and displays
+// it on the Stream Deck, then reloads and displays the config again whenever
+// the file changes until the context is cancelled.
+//
+// An error is returned if the config can't be loaded initially. If reloading
+// the config fails, the error is logged and the previous view remains
+// displayed. Any animations started by a previous view are stopped when the
+// new view is displayed.
+//
+// This function blocks until the context is cancelled.
+func WatchConfig(ctx context.Context, sd *streamdeck.StreamDeck, path string) error {
+	if sd == nil {
+		return errors.New("view: streamdeck cannot be nil")
+	}
+	if err := applyConfig(ctx, sd, path); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("view: failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// Watch the directory rather than the file itself, editors often replace
+	// a file by renaming a new file over it which would remove the watch.
+	dir, name := filepath.Split(filepath.Clean(path))
+	if dir == "" {
+		dir = "."
+	}
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("view: failed to watch %s: %w", dir, err)
+	}
+
+	// reload fires once the config file has stopped changing for watchDelay,
+	// it is only started once the file changes.
+	reload := time.NewTimer(watchDelay)
+	defer reload.Stop()
+	if !reload.Stop() {
+		<-reload.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return errors.New("view: config watcher was closed")
+			}
+			return fmt.Errorf("view: failed to watch config: %w", err)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return errors.New("view: config watcher was closed")
+			}
+			if filepath.Base(event.Name) != name || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			// Restart the delay, draining the timer if it already fired
+			// but the reload hasn't happened yet.
+			if !reload.Stop() {
+				select {
+				case <-reload.C:
+				default:
+				}
+			}
+			reload.Reset(watchDelay)
+		case <-reload.C:
+			if err := applyConfig(ctx, sd, path); err != nil {
+				sd.Logger().Printf("view: failed to reload config %s: %v\n", path, err)
+			}
+		}
+	}
+}
+
+// applyConfig loads the config file at path and displays it on the Stream
+// Deck.
+func applyConfig(ctx context.Context, sd *streamdeck.StreamDeck, path string) error {
+	b, err := LoadConfigFile(sd, path)
+	if err != nil {
+		return err
+	}
+	return sd.SetView(ctx, b)
+}
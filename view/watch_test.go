@@ -0,0 +1,92 @@
+//
+// Copyright (c) 2024 Matthew Penner
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package view_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/streamdeck/streamdecktest"
+	"github.com/matthewpi/streamdeck/view"
+)
+
+// writeConfig writes a config filling the first button with a colour.
+func writeConfig(t *testing.T, path, color string) {
+	t.Helper()
+
+	config := fmt.Sprintf(`{"buttons": {"0": {"color": %q}}}`, color)
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestWatchConfigDebounce checks that a config file written multiple times in
+// quick succession is only reloaded once it stops changing.
+func TestWatchConfigDebounce(t *testing.T) {
+	sd, tr := streamdecktest.NewStreamDeck(t, streamdecktest.DeviceType(t, 0x60))
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfig(t, path, "#000")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- view.WatchConfig(ctx, sd, path)
+	}()
+
+	// Wait for the initial config to be displayed and the file to be
+	// watched.
+	deadline := time.Now().Add(time.Second)
+	for tr.ImageCount(0) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the initial config to be displayed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// Each write is well within the reload delay of the previous one.
+	for _, color := range []string{"#f00", "#0f0", "#00f", "#fff"} {
+		writeConfig(t, path, color)
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(500 * time.Millisecond)
+	if n := tr.ImageCount(0); n != 2 {
+		t.Errorf("expected the config to be reloaded once, it was displayed %d times", n)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected WatchConfig to return context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected WatchConfig to return once the context was cancelled")
+	}
+}